@@ -0,0 +1,430 @@
+package cdiff
+
+import (
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// histogramMaxChain is the limit of occurrences of a line to be used as a split point of histogram diff like git
+const histogramMaxChain = 64
+
+// splitLinesWithTerminator splits text into lines at "\n", "\r\n" and "\r". Each line keeps its terminator.
+// The last line doesn't have a terminator if the text doesn't end with newline.
+func splitLinesWithTerminator(text string) []string {
+	var lines []string
+	for len(text) > 0 {
+		i := strings.IndexAny(text, "\r\n")
+		if i == -1 {
+			lines = append(lines, text)
+			break
+		}
+		end := i + 1
+		if text[i] == '\r' && end < len(text) && text[end] == '\n' {
+			end++
+		}
+		lines = append(lines, text[:end])
+		text = text[end:]
+	}
+	return lines
+}
+
+// splitLinesAtLF splits text into lines at "\n" only like unified diff. Each line keeps its terminator.
+func splitLinesAtLF(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// splitTerminator splits a line into the text and the terminator ("\n", "\r\n", "\r" or "")
+func splitTerminator(line string) (text, terminator string) {
+	switch {
+	case strings.HasSuffix(line, "\r\n"):
+		return line[:len(line)-2], "\r\n"
+	case strings.HasSuffix(line, "\n"), strings.HasSuffix(line, "\r"):
+		return line[:len(line)-1], line[len(line)-1:]
+	}
+	return line, ""
+}
+
+// lineDiffer calculates line diff of texts which lines are converted into ids.
+type lineDiffer struct {
+	a         []int
+	b         []int
+	algorithm DiffType
+	ops       []Ope
+	// blankID is the id of empty line. It is -1 if there are no empty lines
+	blankID int
+}
+
+func (d *lineDiffer) emit(ope Ope, count int) {
+	for i := 0; i < count; i++ {
+		d.ops = append(d.ops, ope)
+	}
+}
+
+// diff appends edit script of a[aLo:aHi] and b[bLo:bHi]
+func (d *lineDiffer) diff(aLo, aHi, bLo, bHi int) {
+	prefix := 0
+	for aLo+prefix < aHi && bLo+prefix < bHi && d.a[aLo+prefix] == d.b[bLo+prefix] {
+		prefix++
+	}
+	d.emit(Keep, prefix)
+	aLo += prefix
+	bLo += prefix
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	switch {
+	case aLo == aHi:
+		d.emit(Insert, bHi-bLo)
+	case bLo == bHi:
+		d.emit(Delete, aHi-aLo)
+	case d.algorithm == Histogram:
+		d.histogram(aLo, aHi, bLo, bHi)
+	default:
+		d.patience(aLo, aHi, bLo, bHi)
+	}
+	d.emit(Keep, suffix)
+}
+
+// patience uses lines that are unique in both sides as anchors
+func (d *lineDiffer) patience(aLo, aHi, bLo, bHi int) {
+	type occurrence struct {
+		aCount, bCount int
+		aPos, bPos     int
+	}
+	occurrences := make(map[int]*occurrence)
+	for i := aLo; i < aHi; i++ {
+		o, ok := occurrences[d.a[i]]
+		if !ok {
+			o = &occurrence{}
+			occurrences[d.a[i]] = o
+		}
+		o.aCount++
+		o.aPos = i
+	}
+	for i := bLo; i < bHi; i++ {
+		if o, ok := occurrences[d.b[i]]; ok {
+			o.bCount++
+			o.bPos = i
+		}
+	}
+	var aPositions, bPositions []int
+	for i := aLo; i < aHi; i++ {
+		if o := occurrences[d.a[i]]; o.aCount == 1 && o.bCount == 1 {
+			aPositions = append(aPositions, o.aPos)
+			bPositions = append(bPositions, o.bPos)
+		}
+	}
+	if len(aPositions) == 0 {
+		d.myers(aLo, aHi, bLo, bHi)
+		return
+	}
+	// patience sorting: longest increasing subsequence of positions in b
+	var piles []int
+	prev := make([]int, len(bPositions))
+	for i, bPos := range bPositions {
+		lo, hi := 0, len(piles)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if bPositions[piles[mid]] < bPos {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		prev[i] = -1
+		if lo > 0 {
+			prev[i] = piles[lo-1]
+		}
+		if lo == len(piles) {
+			piles = append(piles, i)
+		} else {
+			piles[lo] = i
+		}
+	}
+	anchors := make([]int, len(piles))
+	for i, j := len(piles)-1, piles[len(piles)-1]; i >= 0; i, j = i-1, prev[j] {
+		anchors[i] = j
+	}
+	for _, anchor := range anchors {
+		d.diff(aLo, aPositions[anchor], bLo, bPositions[anchor])
+		d.emit(Keep, 1)
+		aLo = aPositions[anchor] + 1
+		bLo = bPositions[anchor] + 1
+	}
+	d.diff(aLo, aHi, bLo, bHi)
+}
+
+// histogram splits texts at the longest common region that contains the least frequent line like git
+func (d *lineDiffer) histogram(aLo, aHi, bLo, bHi int) {
+	positions := make(map[int][]int)
+	for i := aLo; i < aHi; i++ {
+		positions[d.a[i]] = append(positions[d.a[i]], i)
+	}
+	bestCount := histogramMaxChain + 1
+	bestA, bestB, bestLength := -1, -1, 0
+	for bi := bLo; bi < bHi; {
+		next := bi + 1
+		candidates := positions[d.b[bi]]
+		if len(candidates) == 0 || len(candidates) > bestCount {
+			bi = next
+			continue
+		}
+		for _, ai := range candidates {
+			as, bs := ai, bi
+			for as > aLo && bs > bLo && d.a[as-1] == d.b[bs-1] {
+				as--
+				bs--
+			}
+			ae, be := ai+1, bi+1
+			for ae < aHi && be < bHi && d.a[ae] == d.b[be] {
+				ae++
+				be++
+			}
+			if next < be {
+				next = be
+			}
+			count := bestCount
+			for i := as; i < ae; i++ {
+				if c := len(positions[d.a[i]]); c < count {
+					count = c
+				}
+			}
+			if bestLength < ae-as || count < bestCount {
+				bestA, bestB, bestLength, bestCount = as, bs, ae-as, count
+			}
+		}
+		bi = next
+	}
+	if bestLength == 0 {
+		d.myers(aLo, aHi, bLo, bHi)
+		return
+	}
+	d.diff(aLo, bestA, bLo, bestB)
+	d.emit(Keep, bestLength)
+	d.diff(bestA+bestLength, aHi, bestB+bestLength, bHi)
+}
+
+// compact slides each pure insertion or deletion down as far as possible like git.
+// If some positions are available, the lowest one that ends with an empty line is used.
+func (d *lineDiffer) compact() {
+	aPos, bPos := 0, 0
+	for i := 0; i < len(d.ops); {
+		ope := d.ops[i]
+		if ope == Keep {
+			aPos++
+			bPos++
+			i++
+			continue
+		}
+		j := i
+		for j < len(d.ops) && d.ops[j] == ope {
+			j++
+		}
+		lines, pos := d.a, aPos
+		if ope == Insert {
+			lines, pos = d.b, bPos
+		}
+		n := j - i
+		// changes that have both deletion and insertion are not moved
+		if i == 0 || d.ops[i-1] == Keep {
+			maxShift := 0
+			for j+maxShift < len(d.ops) && d.ops[j+maxShift] == Keep && lines[pos+maxShift] == lines[pos+n+maxShift] &&
+				(j+maxShift+1 == len(d.ops) || d.ops[j+maxShift+1] == Keep) {
+				maxShift++
+			}
+			shift := maxShift
+			for k := maxShift; k >= 0; k-- {
+				if lines[pos+n+k-1] == d.blankID {
+					shift = k
+					break
+				}
+			}
+			for k := 0; k < shift; k++ {
+				d.ops[i+k], d.ops[j+k] = Keep, ope
+			}
+			i += shift
+			j += shift
+			aPos += shift
+			bPos += shift
+		}
+		if ope == Delete {
+			aPos += n
+		} else {
+			bPos += n
+		}
+		i = j
+	}
+}
+
+// idToRune converts a line id into a rune for diffmatchpatch. Surrogate area is skipped.
+func idToRune(id int) rune {
+	r := rune(id + 1)
+	if r >= 0xD800 {
+		r += 0x800
+	}
+	return r
+}
+
+// runeToID is the reverse of idToRune
+func runeToID(r rune) int {
+	if r >= 0xD800+0x800 {
+		r -= 0x800
+	}
+	return int(r - 1)
+}
+
+// myers is a fallback for regions that don't have any anchor lines
+func (d *lineDiffer) myers(aLo, aHi, bLo, bHi int) {
+	a := make([]rune, aHi-aLo)
+	for i := range a {
+		a[i] = idToRune(d.a[aLo+i])
+	}
+	b := make([]rune, bHi-bLo)
+	for i := range b {
+		b[i] = idToRune(d.b[bLo+i])
+	}
+	dmp := diffmatchpatch.New()
+	for _, diff := range dmp.DiffMainRunes(a, b, false) {
+		d.emit(Ope(diff.Type), len([]rune(diff.Text)))
+	}
+}
+
+// diffIDs returns edit script of line ids. blankID is the id of empty line or -1.
+func diffIDs(a, b []int, algorithm DiffType, blankID int) []Ope {
+	d := &lineDiffer{
+		a:         a,
+		b:         b,
+		algorithm: algorithm,
+		blankID:   blankID,
+	}
+	if d.algorithm == Myers {
+		d.myers(0, len(d.a), 0, len(d.b))
+	} else {
+		d.diff(0, len(d.a), 0, len(d.b))
+		d.compact()
+	}
+	return d.ops
+}
+
+// calcLineDiffs returns line diffs. Lines are compared after normalized by options.
+func calcLineDiffs(oldText, newText string, options Options) []blockDiff {
+	oldLines := splitLinesWithTerminator(oldText)
+	newLines := splitLinesWithTerminator(newText)
+	ids := make(map[string]int)
+	toIDs := func(lines []string) []int {
+		result := make([]int, len(lines))
+		for i, line := range lines {
+			key := options.normalize(line)
+			id, ok := ids[key]
+			if !ok {
+				id = len(ids)
+				ids[key] = id
+			}
+			result[i] = id
+		}
+		return result
+	}
+	a := toIDs(oldLines)
+	b := toIDs(newLines)
+	blankID := -1
+	if id, ok := ids["\n"]; ok {
+		blankID = id
+	}
+	// normalized blank lines may have "\r" or white spaces
+	var blankIDs map[int]bool
+	if options.IgnoreBlankLines {
+		blankIDs = make(map[int]bool)
+		for key, id := range ids {
+			if strings.TrimSpace(key) == "" {
+				blankIDs[id] = true
+			}
+		}
+	}
+	ops := diffIDs(a, b, options.Type&algorithmMask, blankID)
+	return opsToBlocks(ops, oldLines, newLines, a, b, blankIDs, 1, 1)
+}
+
+// opsToBlocks converts edit script into blocks. oldLineNumber and newLineNumber are line numbers of the first lines.
+//
+// If blankIDs is not nil, changes whose lines are all blank become Keep blocks that have line number of only one side.
+func opsToBlocks(ops []Ope, oldLines, newLines []string, a, b []int, blankIDs map[int]bool, oldLineNumber, newLineNumber int) []blockDiff {
+	var result []blockDiff
+	appendLine := func(ope Ope, line, newLine string, inOld, inNew bool) {
+		last := len(result) - 1
+		if last < 0 || result[last].Ope != ope || (result[last].OldLineNumber == -1) == inOld || (result[last].NewLineNumber == -1) == inNew {
+			b := blockDiff{Ope: ope, OldLineNumber: -1, NewLineNumber: -1}
+			if inOld {
+				b.OldLineNumber = oldLineNumber
+			}
+			if inNew {
+				b.NewLineNumber = newLineNumber
+			}
+			result = append(result, b)
+			last++
+		}
+		result[last].Text += line
+		result[last].NewText += newLine
+		if inOld {
+			oldLineNumber++
+		}
+		if inNew {
+			newLineNumber++
+		}
+	}
+	oldPos, newPos := 0, 0
+	for i := 0; i < len(ops); {
+		if ops[i] == Keep {
+			appendLine(Keep, oldLines[oldPos], newLines[newPos], true, true)
+			oldPos++
+			newPos++
+			i++
+			continue
+		}
+		// deleted lines are placed before inserted lines in each changed region
+		j := i
+		deleted, inserted := 0, 0
+		ignored := blankIDs != nil
+		for ; j < len(ops) && ops[j] != Keep; j++ {
+			if ops[j] == Delete {
+				ignored = ignored && blankIDs[a[oldPos+deleted]]
+				deleted++
+			} else {
+				ignored = ignored && blankIDs[b[newPos+inserted]]
+				inserted++
+			}
+		}
+		for k := 0; k < deleted; k++ {
+			if ignored {
+				appendLine(Keep, oldLines[oldPos+k], "", true, false)
+			} else {
+				appendLine(Delete, oldLines[oldPos+k], "", true, false)
+			}
+		}
+		for k := 0; k < inserted; k++ {
+			if ignored {
+				appendLine(Keep, newLines[newPos+k], "", false, true)
+			} else {
+				appendLine(Insert, newLines[newPos+k], "", false, true)
+			}
+		}
+		oldPos += deleted
+		newPos += inserted
+		i = j
+	}
+	// NewText is kept only if differences are ignored
+	for i := range result {
+		if result[i].NewText == result[i].Text {
+			result[i].NewText = ""
+		}
+	}
+	return result
+}
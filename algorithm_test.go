@@ -0,0 +1,124 @@
+package cdiff
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hunkShapes returns runs of changed lines in each hunk like "-8+2"
+func hunkShapes(r Result) []string {
+	var result []string
+	for _, b := range grouping(r.Lines, 0) {
+		var builder strings.Builder
+		count := 0
+		for i := b.start; i <= b.end; i++ {
+			count++
+			if i == b.end || r.Lines[i+1].Ope != r.Lines[i].Ope {
+				if r.Lines[i].Ope == Insert {
+					builder.WriteString("+")
+				} else {
+					builder.WriteString("-")
+				}
+				builder.WriteString(strconv.Itoa(count))
+				count = 0
+			}
+		}
+		result = append(result, builder.String())
+	}
+	return result
+}
+
+func readFixture(t *testing.T, name string) string {
+	content, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestAlgorithms(t *testing.T) {
+	tests := []struct {
+		name      string
+		oldFile   string
+		newFile   string
+		algorithm DiffType
+		want      []string
+	}{
+		{
+			name:      "myers matches lines of different functions",
+			oldFile:   "handlers_old.txt",
+			newFile:   "handlers_new.txt",
+			algorithm: Myers,
+			want:      []string{"-8+2", "-2+2"},
+		},
+		{
+			name:      "patience keeps the unchanged function",
+			oldFile:   "handlers_old.txt",
+			newFile:   "handlers_new.txt",
+			algorithm: Patience,
+			want:      []string{"+6", "-12"},
+		},
+		{
+			name:      "histogram keeps the unchanged function",
+			oldFile:   "handlers_old.txt",
+			newFile:   "handlers_new.txt",
+			algorithm: Histogram,
+			want:      []string{"+6", "-12"},
+		},
+		{
+			name:      "patience: moved function",
+			oldFile:   "frobnitz_old.c",
+			newFile:   "frobnitz_new.c",
+			algorithm: Patience,
+			want:      []string{"+9", "-1", "-9", "-1+1"},
+		},
+		{
+			name:      "histogram: moved function",
+			oldFile:   "frobnitz_old.c",
+			newFile:   "frobnitz_new.c",
+			algorithm: Histogram,
+			want:      []string{"+9", "-1", "-9", "-1+1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldText := readFixture(t, tt.oldFile)
+			newText := readFixture(t, tt.newFile)
+			result := Diff(oldText, newText, LineByLine|tt.algorithm)
+			assert.Equal(t, tt.want, hunkShapes(result))
+			assert.Equal(t, oldText, result.Old())
+			assert.Equal(t, newText, result.New())
+		})
+	}
+}
+
+func TestAlgorithmsWithWordByWord(t *testing.T) {
+	for _, algorithm := range []DiffType{Patience, Histogram} {
+		assert.Equal(t, "      abc\n-     de[f]\n+     de[g]\n      ghi\n", dumpForTest(Diff(src1, src2, WordByWord|algorithm)))
+	}
+}
+
+func TestAlgorithmsReconstructTexts(t *testing.T) {
+	words := []string{"{", "}", "", "foo()", "bar()", "return"}
+	random := rand.New(rand.NewSource(1))
+	text := func() string {
+		var lines []string
+		for i := random.Intn(20); i > 0; i-- {
+			lines = append(lines, words[random.Intn(len(words))]+"\n")
+		}
+		return strings.Join(lines, "")
+	}
+	for i := 0; i < 200; i++ {
+		oldText, newText := text(), text()
+		for _, algorithm := range []DiffType{Patience, Histogram} {
+			result := Diff(oldText, newText, LineByLine|algorithm)
+			assert.Equal(t, oldText, result.Old())
+			assert.Equal(t, newText, result.New())
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package cdiff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// binarySniffLength is the length of the beginning of contents that IsBinary() checks
+	binarySniffLength = 8000
+	// maxInvalidUTF8Ratio is the maximum ratio of invalid UTF-8 bytes of text contents
+	maxInvalidUTF8Ratio = 0.3
+	// hexDumpWidth is the number of bytes in each row of HexDump()
+	hexDumpWidth = 16
+)
+
+// IsBinary returns true if the content looks like binary data.
+//
+// It checks the beginning of the content. Contents that have NUL bytes or many invalid UTF-8 bytes are binary.
+// UTF-16 contents with byte order mark are text.
+func IsBinary(content []byte) bool {
+	if e := DetectEncoding(content); e == UTF16LE || e == UTF16BE {
+		return false
+	}
+	if len(content) > binarySniffLength {
+		content = content[:binarySniffLength]
+	}
+	if bytes.IndexByte(content, 0) != -1 {
+		return true
+	}
+	invalid := 0
+	for i := 0; i < len(content); {
+		// the last character may be cut by the sniff length
+		if !utf8.FullRune(content[i:]) {
+			break
+		}
+		r, size := utf8.DecodeRune(content[i:])
+		if r == utf8.RuneError && size == 1 {
+			invalid++
+		}
+		i += size
+	}
+	return len(content) > 0 && float64(invalid)/float64(len(content)) > maxInvalidUTF8Ratio
+}
+
+// HexDump returns rows of 16 bytes like "hexdump -C". Each row has the offset, hex values and printable characters.
+func HexDump(data []byte) string {
+	var builder strings.Builder
+	for offset := 0; offset < len(data); offset += hexDumpWidth {
+		row := data[offset:]
+		if len(row) > hexDumpWidth {
+			row = row[:hexDumpWidth]
+		}
+		fmt.Fprintf(&builder, "%08x ", offset)
+		for i := 0; i < hexDumpWidth; i++ {
+			if i == hexDumpWidth/2 {
+				builder.WriteByte(' ')
+			}
+			if i < len(row) {
+				fmt.Fprintf(&builder, " %02x", row[i])
+			} else {
+				builder.WriteString("   ")
+			}
+		}
+		builder.WriteString("  |")
+		for _, b := range row {
+			if b >= 0x20 && b < 0x7f {
+				builder.WriteByte(b)
+			} else {
+				builder.WriteByte('.')
+			}
+		}
+		builder.WriteString("|\n")
+	}
+	return builder.String()
+}
+
+// HexDumpTokenizer splits a row of HexDump() into words of hex values and characters of the printable part
+func HexDumpTokenizer(line string) []string {
+	i := strings.Index(line, "  |")
+	if i == -1 {
+		return WordTokenizer(line)
+	}
+	return append(WordTokenizer(line[:i+3]), CharTokenizer(line[i+3:])...)
+}
+
+// DiffHex calcs diff of binary data by rows of HexDump()
+//
+// Rows are compared as lines, so changes that insert or delete bytes change all following rows.
+// If options doesn't have Tokenizer, changed bytes are highlighted by HexDumpTokenizer unless the type is LineByLine.
+func DiffHex(oldData, newData []byte, options Options) Result {
+	if options.Tokenizer == nil && options.Type&granularityMask != LineByLine {
+		options.Tokenizer = HexDumpTokenizer
+	}
+	return DiffWithOptions(HexDump(oldData), HexDump(newData), options)
+}
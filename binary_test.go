@@ -0,0 +1,47 @@
+package cdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    bool
+	}{
+		{name: "empty", content: []byte{}, want: false},
+		{name: "ascii", content: []byte("hello\nworld\n"), want: false},
+		{name: "utf-8", content: []byte("こんにちは\n"), want: false},
+		{name: "nul", content: []byte("a\x00b"), want: true},
+		{name: "invalid utf-8", content: []byte{0x89, 0x50, 0x4e, 0x47, 0xff, 0xfe, 0x80}, want: true},
+		{name: "a few invalid bytes", content: []byte("caf\xe9 au lait\n"), want: false},
+		{name: "utf-16 with bom", content: []byte{0xff, 0xfe, 'a', 0, '\n', 0}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsBinary(tt.content))
+		})
+	}
+}
+
+func TestHexDump(t *testing.T) {
+	assert.Equal(t, "", HexDump(nil))
+	assert.Equal(t,
+		"00000000  48 65 6c 6c 6f 00 57 6f  72 6c 64 0a 01 02 03 04  |Hello.World.....|\n"+
+			"00000010  ff                                                |.|\n",
+		HexDump([]byte("Hello\x00World\n\x01\x02\x03\x04\xff")))
+}
+
+func TestDiffHex(t *testing.T) {
+	oldData := []byte("0123456789abcdef0123456789abcdef")
+	newData := []byte("0123456789abcdef0123456789abcdeF")
+	got := DiffHex(oldData, newData, Options{Type: WordByWord})
+	assert.Equal(t, HexDump(oldData), got.Old())
+	assert.Equal(t, HexDump(newData), got.New())
+	assert.Equal(t, "  00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|\n"+
+		"- 00000010  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 [66]  |0123456789abcde[f]|\n"+
+		"+ 00000010  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 [46]  |0123456789abcde[F]|\n", dumpForTest(got))
+}
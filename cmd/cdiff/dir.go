@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shibukawa/cdiff"
+)
+
+// fileSummary is a line of summary footer of directory comparison
+type fileSummary struct {
+	status string
+	path   string
+	// newPath is the new path of renamed file
+	newPath  string
+	inserted int
+	deleted  int
+	binary   bool
+}
+
+func (f fileSummary) String() string {
+	var counts []string
+	if f.inserted > 0 {
+		counts = append(counts, fmt.Sprintf("+%d", f.inserted))
+	}
+	if f.deleted > 0 {
+		counts = append(counts, fmt.Sprintf("-%d", f.deleted))
+	}
+	path := f.path
+	if f.newPath != "" {
+		path += " -> " + f.newPath
+	}
+	if len(counts) == 0 {
+		return f.status + ": " + path
+	}
+	return f.status + ": " + path + " (" + strings.Join(counts, " ") + ")"
+}
+
+// isBinary returns true if the content looks like a binary file and --text is not given
+func isBinary(content []byte) bool {
+	return !*text && cdiff.IsBinary(content)
+}
+
+func matchAny(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(relPath)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// walkTree returns relative paths of files under root filtered by --include and --exclude
+func walkTree(root string) ([]string, error) {
+	var result []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if matchAny(*excludes, relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if len(*includes) > 0 && !matchAny(*includes, relPath) {
+			return nil
+		}
+		result = append(result, relPath)
+		return nil
+	})
+	return result, err
+}
+
+func countLines(content []byte) int {
+	count := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		count++
+	}
+	return count
+}
+
+// onlyIn prints a message like "diff -r" for a file exists only one side
+func onlyIn(root, relPath string) {
+	if statMode() {
+		return
+	}
+	path := filepath.Join(root, relPath)
+	fmt.Printf("Only in %s: %s\n", filepath.Dir(path), filepath.Base(path))
+}
+
+// diffDirs compares directories and returns true if they differ
+func diffDirs(oldRoot, newRoot string) bool {
+	oldEncoding, newEncoding := parseEncoding(*oldEncoding), parseEncoding(*newEncoding)
+	oldFiles, err := walkTree(oldRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't read old directory %q: %v", oldRoot, err)
+		os.Exit(exitTrouble)
+	}
+	newFiles, err := walkTree(newRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't read new directory %q: %v", newRoot, err)
+		os.Exit(exitTrouble)
+	}
+	oldSet := make(map[string]bool)
+	for _, f := range oldFiles {
+		oldSet[f] = true
+	}
+	newSet := make(map[string]bool)
+	for _, f := range newFiles {
+		newSet[f] = true
+	}
+
+	// files that exist only in new tree are candidates of renamed files
+	renamedFrom := make(map[[sha1.Size]byte]string)
+	for _, f := range newFiles {
+		if !oldSet[f] {
+			// empty files are not treated as renamed
+			if content, _ := ioutil.ReadFile(filepath.Join(newRoot, f)); len(content) > 0 {
+				renamedFrom[sha1.Sum(content)] = f
+			}
+		}
+	}
+	renamedTo := make(map[string]string)
+	for _, f := range oldFiles {
+		if !newSet[f] {
+			content, _ := ioutil.ReadFile(filepath.Join(oldRoot, f))
+			if newFile, ok := renamedFrom[sha1.Sum(content)]; ok {
+				renamedTo[f] = newFile
+				delete(renamedFrom, sha1.Sum(content))
+			}
+		}
+	}
+	renamedNew := make(map[string]bool)
+	for _, f := range renamedTo {
+		renamedNew[f] = true
+	}
+
+	all := append([]string{}, oldFiles...)
+	for _, f := range newFiles {
+		if !oldSet[f] {
+			all = append(all, f)
+		}
+	}
+	sort.Strings(all)
+
+	var summaries []fileSummary
+	for _, relPath := range all {
+		oldPath := filepath.Join(oldRoot, relPath)
+		newPath := filepath.Join(newRoot, relPath)
+		switch {
+		case renamedNew[relPath]:
+			continue
+		case renamedTo[relPath] != "":
+			if !statMode() {
+				fmt.Printf("Renamed: %s -> %s\n", oldPath, filepath.Join(newRoot, renamedTo[relPath]))
+			}
+			summaries = append(summaries, fileSummary{status: "renamed", path: relPath, newPath: renamedTo[relPath]})
+		case !newSet[relPath]:
+			onlyIn(oldRoot, relPath)
+			content, _ := ioutil.ReadFile(oldPath)
+			summary := fileSummary{status: "removed", path: relPath, binary: isBinary(content)}
+			if !summary.binary {
+				summary.deleted = countLines(content)
+			}
+			summaries = append(summaries, summary)
+		case !oldSet[relPath]:
+			onlyIn(newRoot, relPath)
+			content, _ := ioutil.ReadFile(newPath)
+			summary := fileSummary{status: "added", path: relPath, binary: isBinary(content)}
+			if !summary.binary {
+				summary.inserted = countLines(content)
+			}
+			summaries = append(summaries, summary)
+		default:
+			oldDoc, oldEncoding := readDoc("old", oldPath, oldEncoding)
+			newDoc, newEncoding := readDoc("new", newPath, newEncoding)
+			if oldDoc == newDoc && oldEncoding == newEncoding {
+				if !statMode() {
+					reportFiles(oldPath, newPath, false)
+				}
+				continue
+			}
+			if binaryFiles(oldDoc, newDoc) {
+				if *brief {
+					reportFiles(oldPath, newPath, true)
+				} else if !statMode() {
+					fmt.Printf("Binary files %s and %s differ\n", oldPath, newPath)
+				}
+				summaries = append(summaries, fileSummary{status: "modified", path: relPath, binary: true})
+				continue
+			}
+			diff := compare(oldDoc, newDoc)
+			if !diff.HasChanges() && oldEncoding == newEncoding {
+				if !statMode() {
+					reportFiles(oldPath, newPath, false)
+				}
+				continue
+			}
+			if *brief {
+				reportFiles(oldPath, newPath, true)
+			} else if !statMode() {
+				fmt.Printf("diff -r %s %s\n", oldPath, newPath)
+				header := fileHeader(oldPath, newPath)
+				header.OldEncoding, header.NewEncoding = oldEncoding, newEncoding
+				render(diff, header)
+			}
+			stats := diff.Stats()
+			summaries = append(summaries, fileSummary{status: "modified", path: relPath, inserted: stats.Inserted, deleted: stats.Deleted})
+		}
+	}
+
+	if statMode() {
+		printStats(os.Stdout, summaries)
+		return len(summaries) > 0
+	}
+	if *brief || len(summaries) == 0 {
+		return len(summaries) > 0
+	}
+	fmt.Println()
+	totalInserted, totalDeleted := 0, 0
+	for _, s := range summaries {
+		fmt.Println(s)
+		totalInserted += s.inserted
+		totalDeleted += s.deleted
+	}
+	fmt.Printf("%d files changed, %d insertions(+), %d deletions(-)\n", len(summaries), totalInserted, totalDeleted)
+	return true
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/shibukawa/cdiff"
+)
+
+var (
+	gitHexPattern  = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64}|\.)$`)
+	gitModePattern = regexp.MustCompile(`^([0-7]{6}|\.)$`)
+)
+
+// gitExternalDiff is arguments of GIT_EXTERNAL_DIFF:
+//
+//	path old-file old-hex old-mode new-file new-hex new-mode [new-path metadata]
+//
+// Unmerged paths have only path.
+type gitExternalDiff struct {
+	path     string
+	unmerged bool
+	oldFile  string
+	oldHex   string
+	oldMode  string
+	newFile  string
+	newHex   string
+	newMode  string
+	newPath  string
+	metadata string
+}
+
+// parseGitExternalDiff returns arguments if args follows the calling convention of GIT_EXTERNAL_DIFF
+func parseGitExternalDiff(args []string) (gitExternalDiff, bool) {
+	// git sets GIT_DIFF_PATH_TOTAL for external diffs. It tells a path from a command like "cdiff help".
+	if len(args) == 1 && os.Getenv("GIT_DIFF_PATH_TOTAL") != "" {
+		return gitExternalDiff{path: args[0], unmerged: true}, true
+	}
+	if len(args) != 7 && len(args) != 9 {
+		return gitExternalDiff{}, false
+	}
+	if !gitHexPattern.MatchString(args[2]) || !gitHexPattern.MatchString(args[5]) ||
+		!gitModePattern.MatchString(args[3]) || !gitModePattern.MatchString(args[6]) {
+		return gitExternalDiff{}, false
+	}
+	result := gitExternalDiff{
+		path:    args[0],
+		oldFile: args[1],
+		oldHex:  args[2],
+		oldMode: args[3],
+		newFile: args[4],
+		newHex:  args[5],
+		newMode: args[6],
+		newPath: args[0],
+	}
+	if len(args) == 9 {
+		result.newPath = args[7]
+		result.metadata = args[8]
+	}
+	return result, true
+}
+
+// gitValue returns "" for "." that means the file doesn't exist
+func gitValue(value string) string {
+	if value == "." {
+		return ""
+	}
+	return value
+}
+
+// header returns a header that has "diff --git" line, modes and hashes like git
+func (g gitExternalDiff) header() cdiff.FileHeader {
+	result := cdiff.FileHeader{
+		Headers: []string{"diff --git a/" + g.path + " b/" + g.newPath},
+		OldMode: gitValue(g.oldMode),
+		NewMode: gitValue(g.newMode),
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(g.metadata, "\n"), "\n") {
+		// metadata of renamed files has "index" line too, but it is generated from hashes
+		if line != "" && !strings.HasPrefix(line, "index ") {
+			result.Headers = append(result.Headers, line)
+		}
+	}
+	oldHash, newHash := objectID(g.oldHex, g.oldMode, g.oldFile), objectID(g.newHex, g.newMode, g.newFile)
+	unknown := (oldHash == "" && g.oldHex != ".") || (newHash == "" && g.newHex != ".")
+	// git doesn't write "index" line for mode changes
+	if oldHash != newHash && !unknown {
+		result.OldHash, result.NewHash = oldHash, newHash
+	}
+	return result
+}
+
+// objectID returns the object id of a file. git passes all-zero id for files in the working tree,
+// so it is computed from the content like "git hash-object". It returns "" if the id is unknown.
+func objectID(id, mode, file string) string {
+	if strings.Trim(id, "0") != "" {
+		return gitValue(id)
+	}
+	var content []byte
+	var err error
+	if mode == "120000" {
+		// the content of a symbolic link is its target
+		var target string
+		target, err = os.Readlink(file)
+		content = []byte(target)
+	} else {
+		content, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return ""
+	}
+	h := sha1.New()
+	if len(id) == 64 {
+		h = sha256.New()
+	}
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// titles returns file names of "---" and "+++" lines
+func (g gitExternalDiff) titles() (string, string) {
+	oldTitle, newTitle := "a/"+g.path, "b/"+g.newPath
+	if g.oldMode == "." {
+		oldTitle = "/dev/null"
+	}
+	if g.newMode == "." {
+		newTitle = "/dev/null"
+	}
+	return oldTitle, newTitle
+}
+
+// diffGitExternal writes a diff of a file for git. git stops if an external diff exits with non-zero status.
+func diffGitExternal(g gitExternalDiff) {
+	if g.unmerged {
+		fmt.Printf("* Unmerged path %s\n", g.path)
+		return
+	}
+	oldDoc, oldEncoding := readDoc("old", g.oldFile, "")
+	newDoc, newEncoding := readDoc("new", g.newFile, "")
+	oldTitle, newTitle := g.titles()
+	header := g.header()
+	header.OldEncoding, header.NewEncoding = oldEncoding, newEncoding
+	var diff cdiff.Result
+	binary := binaryFiles(oldDoc, newDoc)
+	if !binary {
+		diff = compare(oldDoc, newDoc)
+	}
+	// mode changes don't have "---" and "+++" lines
+	if diff.HasChanges() {
+		header.OldTitle, header.NewTitle = oldTitle, newTitle
+	}
+	writer := bufio.NewWriter(os.Stdout)
+	diff.RenderWithHeader(writer, header, *length, cdiff.GooKitColorThemeRenderer)
+	writer.Flush()
+	if binary && oldDoc != newDoc {
+		fmt.Printf("Binary files %s and %s differ\n", oldTitle, newTitle)
+	}
+}
+
+// filter re-renders "git diff" output from standard input
+func filter() {
+	writer := bufio.NewWriter(os.Stdout)
+	defer writer.Flush()
+	err := cdiff.FilterUnifiedWithGooKitColor(writer, os.Stdin, granularityType(*filterGranularity), cdiff.GooKitColorTheme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't read diff: %v", err)
+		os.Exit(exitTrouble)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const zeroID = "0000000000000000000000000000000000000000"
+
+func TestGitExternalDiffHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cdiff")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "hello.txt")
+	assert.Nil(t, ioutil.WriteFile(file, []byte("hello\n"), 0644))
+
+	tests := []struct {
+		name    string
+		g       gitExternalDiff
+		oldHash string
+		newHash string
+	}{
+		{
+			name:    "working tree",
+			g:       gitExternalDiff{oldHex: "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391", oldMode: "100644", newFile: file, newHex: zeroID, newMode: "100644"},
+			oldHash: "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391",
+			newHash: "ce013625030ba8dba906f756967f9e9ca394464a",
+		},
+		{
+			name: "mode change in working tree",
+			g:    gitExternalDiff{oldHex: "ce013625030ba8dba906f756967f9e9ca394464a", oldMode: "100644", newFile: file, newHex: zeroID, newMode: "100755"},
+		},
+		{
+			name: "unknown object",
+			g:    gitExternalDiff{oldHex: "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391", oldMode: "100644", newFile: filepath.Join(dir, "missing"), newHex: zeroID, newMode: "100644"},
+		},
+		{
+			name:    "new file",
+			g:       gitExternalDiff{oldHex: ".", oldMode: ".", newFile: file, newHex: zeroID, newMode: "100644"},
+			newHash: "ce013625030ba8dba906f756967f9e9ca394464a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.g.header()
+			assert.Equal(t, tt.oldHash, header.OldHash)
+			assert.Equal(t, tt.newHash, header.NewHash)
+		})
+	}
+}
+
+func TestParseGitExternalDiff(t *testing.T) {
+	defer os.Unsetenv("GIT_DIFF_PATH_TOTAL")
+	tests := []struct {
+		name  string
+		args  []string
+		env   string
+		want  gitExternalDiff
+		valid bool
+	}{
+		{
+			name:  "modified",
+			args:  []string{"f", "/tmp/f", "ce013625030ba8dba906f756967f9e9ca394464a", "100644", "f", zeroID, "100644"},
+			want:  gitExternalDiff{path: "f", oldFile: "/tmp/f", oldHex: "ce013625030ba8dba906f756967f9e9ca394464a", oldMode: "100644", newFile: "f", newHex: zeroID, newMode: "100644", newPath: "f"},
+			valid: true,
+		},
+		{
+			name:  "unmerged",
+			args:  []string{"f"},
+			env:   "1",
+			want:  gitExternalDiff{path: "f", unmerged: true},
+			valid: true,
+		},
+		{
+			name: "command",
+			args: []string{"help"},
+		},
+		{
+			name: "files",
+			args: []string{"old.txt", "new.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("GIT_DIFF_PATH_TOTAL", tt.env)
+			got, ok := parseGitExternalDiff(tt.args)
+			assert.Equal(t, tt.valid, ok)
+			if tt.valid {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -1,33 +1,487 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
-	"github.com/gookit/color"
 	"github.com/shibukawa/cdiff"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 var (
-	length     = kingpin.Flag("unified", "output NUM (default 3) lines of unified context").Short('U').Default("3").PlaceHolder("NUM").Int()
-	oldDocPath = kingpin.Arg("OLD", "old file to compare").Required().ExistingFile()
-	newDocPath = kingpin.Arg("NEW", "new file to compare").Required().ExistingFile()
+	diffCommand       = kingpin.Command("diff", "compare files line by line (default)").Default()
+	length            = diffCommand.Flag("unified", "output NUM (default 3) lines of unified context").Short('U').Default("3").PlaceHolder("NUM").Int()
+	sideBySide        = diffCommand.Flag("side-by-side", "output in two columns").Short('y').Bool()
+	width             = diffCommand.Flag("width", "output at most NUM (default: terminal width) print columns").Short('W').PlaceHolder("NUM").Int()
+	wrap              = diffCommand.Flag("wrap", "wrap long lines instead of truncating them in side-by-side mode").Bool()
+	algorithm         = diffCommand.Flag("diff-algorithm", "choose a diff algorithm (myers, patience, histogram)").Default("myers").Enum("myers", "patience", "histogram")
+	ignoreAllSpace    = diffCommand.Flag("ignore-all-space", "ignore all white space").Short('w').Bool()
+	ignoreSpaceChange = diffCommand.Flag("ignore-space-change", "ignore changes in the amount of white space").Short('b').Bool()
+	ignoreBlankLines  = diffCommand.Flag("ignore-blank-lines", "ignore changes whose lines are all blank").Short('B').Bool()
+	stripTrailingCR   = diffCommand.Flag("strip-trailing-cr", "strip trailing carriage return on input").Bool()
+	text              = diffCommand.Flag("text", "treat all files as text").Short('a').Bool()
+	hexDump           = diffCommand.Flag("hex", "compare binary files by hex dump of 16 byte rows").Bool()
+	ignoreLineEnding  = diffCommand.Flag("ignore-line-ending", "ignore differences of line endings (LF, CRLF and CR)").Bool()
+	oldEncoding       = diffCommand.Flag("old-encoding", "decode the old file from ENCODING (utf-8, utf-8-bom, utf-16le, utf-16be, shift_jis, euc-jp, latin1; default: detect by BOM)").PlaceHolder("ENCODING").String()
+	newEncoding       = diffCommand.Flag("new-encoding", "decode the new file from ENCODING (utf-8, utf-8-bom, utf-16le, utf-16be, shift_jis, euc-jp, latin1; default: detect by BOM)").PlaceHolder("ENCODING").String()
+	stream            = diffCommand.Flag("stream", "compare large files without loading whole files (unified format only)").Bool()
+	format            = diffCommand.Flag("format", "output format (unified, json)").Default("unified").Enum("unified", "json")
+	htmlOutput        = diffCommand.Flag("html", "output a standalone HTML document").Bool()
+	colorMoved        = diffCommand.Flag("color-moved", "color moved blocks of lines differently").Bool()
+	granularity       = diffCommand.Flag("granularity", "highlight changes in lines by (line, word, token, char)").Default("word").Enum("line", "word", "token", "char")
+	brief             = diffCommand.Flag("brief", "report only when files differ").Short('q').Bool()
+	reportIdentical   = diffCommand.Flag("report-identical-files", "report when two files are the same").Short('s').Bool()
+	stat              = diffCommand.Flag("stat", "output a diffstat like git").Bool()
+	shortStat         = diffCommand.Flag("shortstat", "output only the last line of --stat").Bool()
+	numStat           = diffCommand.Flag("numstat", "output numbers of inserted and deleted lines in a machine friendly format").Bool()
+	includes          = diffCommand.Flag("include", "compare only files that match PATTERN in directory comparison").PlaceHolder("PATTERN").Strings()
+	excludes          = diffCommand.Flag("exclude", "exclude files that match PATTERN in directory comparison").Short('x').PlaceHolder("PATTERN").Strings()
+	oldLabel          = diffCommand.Flag("old-label", "use LABEL instead of the old file name in headers").PlaceHolder("LABEL").String()
+	newLabel          = diffCommand.Flag("new-label", "use LABEL instead of the new file name in headers").PlaceHolder("LABEL").String()
+	oldDocPath        = diffCommand.Arg("OLD", "old file or directory to compare ('-' for standard input)").Required().String()
+	newDocPath        = diffCommand.Arg("NEW", "new file or directory to compare ('-' for standard input)").Required().String()
+
+	filterCommand     = kingpin.Command("filter", "re-render unified diff from standard input with word level highlighting (for git core.pager and interactive.diffFilter)")
+	filterGranularity = filterCommand.Flag("granularity", "highlight changes in lines by (line, word, token, char)").Default("word").Enum("line", "word", "token", "char")
+
+	mergeCommand = kingpin.Command("merge", "three-way merge of files")
+	diff3        = mergeCommand.Flag("diff3", "output base lines in conflicts").Bool()
+	oursPath     = mergeCommand.Arg("OURS", "our modified file").Required().ExistingFile()
+	basePath     = mergeCommand.Arg("BASE", "common base file").Required().ExistingFile()
+	theirsPath   = mergeCommand.Arg("THEIRS", "their modified file").Required().ExistingFile()
 )
 
-func main() {
-	kingpin.Parse()
-	oldDoc, err := ioutil.ReadFile(*oldDocPath)
+// exit statuses like diff(1). merge exits with exitConflict like "diff3 -m" and "git merge-file".
+const (
+	exitDiffer   = 1
+	exitConflict = 1
+	exitTrouble  = 2
+)
+
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 130
+}
+
+// stdinPath is a path that means standard input
+const stdinPath = "-"
+
+// stdinArg replaces "-" in command line arguments because kingpin treats "-" as a short flag.
+// It has NUL that can't be in paths.
+const stdinArg = "\x00stdin"
+
+// parseArgs parses command line arguments that may have "-" for standard input
+func parseArgs(args []string) string {
+	replaced := make([]string, len(args))
+	for i, arg := range args {
+		if arg == stdinPath {
+			arg = stdinArg
+		}
+		replaced[i] = arg
+	}
+	command := kingpin.MustParse(kingpin.CommandLine.Parse(replaced))
+	for _, value := range []*string{oldDocPath, newDocPath, oldLabel, newLabel} {
+		if *value == stdinArg {
+			*value = stdinPath
+		}
+	}
+	return command
+}
+
+// checkInputs validates OLD and NEW. Non-regular files like FIFOs of process substitution are accepted.
+func checkInputs(oldPath, newPath string) {
+	if oldPath == stdinPath && newPath == stdinPath {
+		kingpin.Fatalf("only one of OLD and NEW can be standard input")
+	}
+	for _, path := range []string{oldPath, newPath} {
+		if path == stdinPath {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			kingpin.Fatalf("path '%s' does not exist", path)
+		}
+	}
+	if (oldPath == stdinPath && isDir(newPath)) || (newPath == stdinPath && isDir(oldPath)) {
+		kingpin.Fatalf("can't compare standard input with a directory")
+	}
+}
+
+// openFile opens a file. It returns standard input for "-".
+func openFile(path string) (io.ReadCloser, error) {
+	if path == stdinPath {
+		return ioutil.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// labels returns titles of headers. --old-label and --new-label override paths.
+func labels(oldPath, newPath string) (string, string) {
+	if *oldLabel != "" {
+		oldPath = *oldLabel
+	}
+	if *newLabel != "" {
+		newPath = *newLabel
+	}
+	return oldPath, newPath
+}
+
+// fileHeader returns a header that has labels or paths and modification times like "diff -u"
+func fileHeader(oldPath, newPath string) cdiff.FileHeader {
+	oldTitle, newTitle := labels(oldPath, newPath)
+	header := cdiff.FileHeader{OldTitle: oldTitle, NewTitle: newTitle}
+	// labels replace timestamps too like diff(1)
+	if info, err := os.Stat(oldPath); err == nil && oldPath != stdinPath && *oldLabel == "" {
+		header.OldTime = info.ModTime()
+	}
+	if info, err := os.Stat(newPath); err == nil && newPath != stdinPath && *newLabel == "" {
+		header.NewTime = info.ModTime()
+	}
+	return header
+}
+
+func readFile(kind, path string) string {
+	file, err := openFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't open %s document %q: %v", kind, path, err)
+		os.Exit(exitTrouble)
+	}
+	defer file.Close()
+	doc, err := ioutil.ReadAll(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't open %s document %q: %v", kind, path, err)
+		os.Exit(exitTrouble)
+	}
+	return string(doc)
+}
+
+// parseEncoding returns the encoding of --old-encoding or --new-encoding. An empty name means detection by BOM.
+func parseEncoding(name string) cdiff.Encoding {
+	if name == "" {
+		return ""
+	}
+	e, err := cdiff.ParseEncoding(name)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't open old document %q: %v", *oldDocPath, err)
-		os.Exit(1)
+		kingpin.Fatalf("%v", err)
+	}
+	return e
+}
+
+// readDoc reads a file and decodes it into UTF-8. It returns the encoding of the file too.
+// UTF-8 files without BOM are returned as they are to check binary files.
+func readDoc(kind, path string, e cdiff.Encoding) (string, cdiff.Encoding) {
+	doc := readFile(kind, path)
+	if e == "" {
+		e = cdiff.DetectEncoding([]byte(doc))
 	}
-	newDoc, err := ioutil.ReadFile(*newDocPath)
+	if e == cdiff.UTF8 {
+		return doc, e
+	}
+	decoded, err := e.Decode([]byte(doc))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't open new document %q: %v", *newDocPath, err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Can't decode %s document %q as %s: %v", kind, path, e, err)
+		os.Exit(exitTrouble)
+	}
+	return decoded, e
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// granularityType returns DiffType of --granularity
+func granularityType(granularity string) cdiff.DiffType {
+	switch granularity {
+	case "line":
+		return cdiff.LineByLine
+	case "token":
+		return cdiff.TokenByToken
+	case "char":
+		return cdiff.CharByChar
+	}
+	return cdiff.WordByWord
+}
+
+func diffType() cdiff.DiffType {
+	result := granularityType(*granularity)
+	switch *algorithm {
+	case "patience":
+		return result | cdiff.Patience
+	case "histogram":
+		return result | cdiff.Histogram
+	}
+	return result
+}
+
+func diffOptions() cdiff.Options {
+	return cdiff.Options{
+		Type:              diffType(),
+		IgnoreAllSpace:    *ignoreAllSpace,
+		IgnoreSpaceChange: *ignoreSpaceChange,
+		IgnoreBlankLines:  *ignoreBlankLines,
+		StripTrailingCR:   *stripTrailingCR,
+		IgnoreLineEnding:  *ignoreLineEnding,
+		DetectMoves:       *colorMoved,
+	}
+}
+
+// binaryFiles returns true if documents should be reported as "Binary files A and B differ"
+func binaryFiles(oldDoc, newDoc string) bool {
+	return !*hexDump && (isBinary([]byte(oldDoc)) || isBinary([]byte(newDoc)))
+}
+
+// compare returns diff of documents. Binary documents are compared by hex dump if --hex is given.
+func compare(oldDoc, newDoc string) cdiff.Result {
+	if *hexDump && (isBinary([]byte(oldDoc)) || isBinary([]byte(newDoc))) {
+		return cdiff.DiffHex([]byte(oldDoc), []byte(newDoc), diffOptions())
+	}
+	return cdiff.DiffWithOptions(oldDoc, newDoc, diffOptions())
+}
+
+// sniffBinary returns true if the beginning of the file looks like binary.
+// Standard input and non-regular files like FIFOs are not checked because reading them consumes data.
+func sniffBinary(path string) bool {
+	if path == stdinPath {
+		return false
+	}
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	content := make([]byte, 8000)
+	n, _ := io.ReadFull(file, content)
+	return isBinary(content[:n])
+}
+
+// diffBinary reports binary files like diff(1) and returns true if they differ
+func diffBinary(oldPath, newPath string, differ bool) bool {
+	switch {
+	case *brief:
+		return reportFiles(oldPath, newPath, differ)
+	case statMode():
+		if differ {
+			summary := statSummary(oldPath, newPath)
+			summary.binary = true
+			printStats(os.Stdout, []fileSummary{summary})
+		}
+	case differ:
+		fmt.Printf("Binary files %s and %s differ\n", oldPath, newPath)
+	default:
+		reportFiles(oldPath, newPath, false)
+	}
+	return differ
+}
+
+// statSummary returns a summary of single file comparison for --stat
+func statSummary(oldPath, newPath string) fileSummary {
+	summary := fileSummary{status: "modified", path: oldPath}
+	if newPath != oldPath {
+		summary.newPath = newPath
+	}
+	return summary
+}
+
+func renderHTML(diff cdiff.Result, oldPath, newPath string) string {
+	options := cdiff.HTMLOptions{Standalone: true, Title: oldPath + " - " + newPath}
+	if *sideBySide {
+		options.Layout = cdiff.HTMLSplit
+	}
+	var builder strings.Builder
+	diff.HTML(&builder, oldPath, newPath, *length, options)
+	return builder.String()
+}
+
+// render writes the diff to standard output
+func render(diff cdiff.Result, header cdiff.FileHeader) {
+	if *sideBySide {
+		if *width <= 0 {
+			*width = terminalWidth()
+		}
+		fmt.Print(diff.SideBySideWithGooKitColor(header.OldTitle, header.NewTitle, *length, *width, *wrap, cdiff.GooKitColorTheme))
+		return
+	}
+	writer := bufio.NewWriter(os.Stdout)
+	defer writer.Flush()
+	diff.RenderWithHeader(writer, header, *length, cdiff.GooKitColorThemeRenderer)
+}
+
+// changeDetector is a Renderer that remembers whether inserted or deleted lines are rendered
+type changeDetector struct {
+	cdiff.Renderer
+	changed bool
+}
+
+func (c *changeDetector) Line(w io.Writer, l cdiff.Line) error {
+	if l.Ope != cdiff.Keep {
+		c.changed = true
+	}
+	return c.Renderer.Line(w, l)
+}
+
+// diffStream writes unified diff of large files and returns true if they differ
+func diffStream(oldPath, newPath string) bool {
+	oldFile, err := openFile(oldPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't open old document %q: %v", oldPath, err)
+		os.Exit(exitTrouble)
+	}
+	defer oldFile.Close()
+	newFile, err := openFile(newPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't open new document %q: %v", newPath, err)
+		os.Exit(exitTrouble)
+	}
+	defer newFile.Close()
+	oldReader, oldEncoding, err := cdiff.NewDecodeReader(oldFile, parseEncoding(*oldEncoding))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't read old document %q: %v", oldPath, err)
+		os.Exit(exitTrouble)
+	}
+	newReader, newEncoding, err := cdiff.NewDecodeReader(newFile, parseEncoding(*newEncoding))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't read new document %q: %v", newPath, err)
+		os.Exit(exitTrouble)
+	}
+	header := fileHeader(oldPath, newPath)
+	header.OldEncoding, header.NewEncoding = oldEncoding, newEncoding
+	writer := bufio.NewWriter(os.Stdout)
+	defer writer.Flush()
+	renderer := &changeDetector{Renderer: cdiff.GooKitColorThemeRenderer}
+	err = cdiff.RenderReaderWithHeader(writer, oldReader, newReader, header, *length, diffOptions(), renderer)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't compare documents: %v", err)
+		os.Exit(exitTrouble)
+	}
+	return renderer.changed || oldEncoding != newEncoding
+}
+
+// reportFiles prints a message of -q and -s options and returns true if the files differ
+func reportFiles(oldPath, newPath string, differ bool) bool {
+	if differ {
+		fmt.Printf("Files %s and %s differ\n", oldPath, newPath)
+	} else if *reportIdentical {
+		fmt.Printf("Files %s and %s are identical\n", oldPath, newPath)
+	}
+	return differ
+}
+
+// diff compares files or directories and returns true if they differ
+func diff() bool {
+	oldPath, newPath := *oldDocPath, *newDocPath
+	checkInputs(oldPath, newPath)
+	oldEncoding, newEncoding := parseEncoding(*oldEncoding), parseEncoding(*newEncoding)
+	switch oldIsDir, newIsDir := isDir(oldPath), isDir(newPath); {
+	case oldIsDir && newIsDir:
+		if *htmlOutput || *format == "json" {
+			kingpin.Fatalf("--html and --format=json are not supported for directories")
+		}
+		return diffDirs(oldPath, newPath)
+	// compare a file with the file of the same name in the directory like diff(1)
+	case oldIsDir:
+		oldPath = filepath.Join(oldPath, filepath.Base(newPath))
+	case newIsDir:
+		newPath = filepath.Join(newPath, filepath.Base(oldPath))
+	}
+	if *stream && !*brief && !statMode() && !*sideBySide && !*htmlOutput && *format == "unified" &&
+		!sniffBinary(oldPath) && !sniffBinary(newPath) {
+		differ := diffStream(oldPath, newPath)
+		if !differ && *reportIdentical {
+			reportFiles(oldPath, newPath, false)
+		}
+		return differ
+	}
+	oldDoc, oldEncoding := readDoc("old", oldPath, oldEncoding)
+	newDoc, newEncoding := readDoc("new", newPath, newEncoding)
+	if binaryFiles(oldDoc, newDoc) {
+		return diffBinary(oldPath, newPath, oldDoc != newDoc)
+	}
+	diff := compare(oldDoc, newDoc)
+	// files that have the same text in different encodings differ too
+	differ := diff.HasChanges() || oldEncoding != newEncoding
+	if *brief {
+		return reportFiles(oldPath, newPath, differ)
+	}
+	if statMode() {
+		summary := statSummary(oldPath, newPath)
+		stats := diff.Stats()
+		if stats.Inserted > 0 || stats.Deleted > 0 {
+			summary.inserted, summary.deleted = stats.Inserted, stats.Deleted
+			printStats(os.Stdout, []fileSummary{summary})
+		}
+		return differ
+	}
+	oldTitle, newTitle := labels(oldPath, newPath)
+	if *htmlOutput {
+		fmt.Print(renderHTML(diff, oldTitle, newTitle))
+		return differ
+	}
+	if *format == "json" {
+		file := cdiff.FileResult{OldName: oldTitle, NewName: newTitle, Result: diff}
+		if err := file.JSON(os.Stdout, *length); err != nil {
+			fmt.Fprintf(os.Stderr, "Can't write JSON: %v", err)
+			os.Exit(exitTrouble)
+		}
+		return differ
+	}
+	if !differ {
+		return reportFiles(oldPath, newPath, false)
+	}
+	header := fileHeader(oldPath, newPath)
+	header.OldEncoding, header.NewEncoding = oldEncoding, newEncoding
+	render(diff, header)
+	return true
+}
+
+func merge() {
+	ours := readFile("our", *oursPath)
+	base := readFile("base", *basePath)
+	theirs := readFile("their", *theirsPath)
+	result := cdiff.Merge3(base, ours, theirs)
+	fmt.Print(result.Format(*oursPath, *basePath, *theirsPath, *diff3))
+	if result.HasConflicts() {
+		for _, c := range result.Conflicts() {
+			fmt.Fprintf(os.Stderr, "conflict: %s:%d, %s:%d, %s:%d\n", *oursPath, c.OursLineNumber, *basePath, c.BaseLineNumber, *theirsPath, c.TheirsLineNumber)
+		}
+		os.Exit(exitConflict)
+	}
+}
+
+func main() {
+	// usage errors are troubles like diff(1)
+	kingpin.CommandLine.Terminate(func(status int) {
+		if status != 0 {
+			status = exitTrouble
+		}
+		os.Exit(status)
+	})
+	// git runs GIT_EXTERNAL_DIFF with 1, 7 or 9 arguments
+	if g, ok := parseGitExternalDiff(os.Args[1:]); ok {
+		if !g.unmerged {
+			parseArgs([]string{"diff", g.oldFile, g.newFile})
+		}
+		diffGitExternal(g)
+		return
+	}
+	switch parseArgs(os.Args[1:]) {
+	case diffCommand.FullCommand():
+		if diff() {
+			os.Exit(exitDiffer)
+		}
+	case filterCommand.FullCommand():
+		filter()
+	case mergeCommand.FullCommand():
+		merge()
 	}
-	diff := cdiff.Diff(string(oldDoc), string(newDoc), cdiff.WordByWord)
-	color.Print(diff.Unified(*oldDocPath, *newDocPath, *length, cdiff.GooKitColorTheme))
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/mattn/go-runewidth"
+)
+
+// statMode returns true if one of --stat, --shortstat and --numstat is given
+func statMode() bool {
+	return *stat || *shortStat || *numStat
+}
+
+// statPath returns a path for statistics like git. Renamed files are shown as "old => new".
+func (f fileSummary) statPath() string {
+	if f.newPath == "" {
+		return f.path
+	}
+	return f.path + " => " + f.newPath
+}
+
+func plural(count int, singular, plural string) string {
+	if count == 1 {
+		return strconv.Itoa(count) + " " + singular
+	}
+	return strconv.Itoa(count) + " " + plural
+}
+
+// shortStatLine returns a summary line like "git diff --shortstat"
+func shortStatLine(summaries []fileSummary) string {
+	inserted, deleted := 0, 0
+	for _, s := range summaries {
+		inserted += s.inserted
+		deleted += s.deleted
+	}
+	result := " " + plural(len(summaries), "file changed", "files changed")
+	if inserted > 0 || deleted == 0 {
+		result += ", " + plural(inserted, "insertion(+)", "insertions(+)")
+	}
+	if deleted > 0 || inserted == 0 {
+		result += ", " + plural(deleted, "deletion(-)", "deletions(-)")
+	}
+	return result
+}
+
+// scaleLinear scales count to width like git
+func scaleLinear(count, width, max int) int {
+	if count == 0 {
+		return 0
+	}
+	return 1 + count*(width-1)/max
+}
+
+// scaleStat scales numbers of inserted and deleted lines to graphWidth like "git diff --stat".
+// Both of them are shown if they are not zero, and the smaller one is scaled to keep the total.
+func scaleStat(inserted, deleted, graphWidth, maxChange int) (int, int) {
+	if graphWidth > maxChange {
+		return inserted, deleted
+	}
+	total := scaleLinear(inserted+deleted, graphWidth, maxChange)
+	if total < 2 && inserted > 0 && deleted > 0 {
+		total = 2
+	}
+	if inserted < deleted {
+		inserted = scaleLinear(inserted, graphWidth, maxChange)
+		return inserted, total - inserted
+	}
+	deleted = scaleLinear(deleted, graphWidth, maxChange)
+	return total - deleted, deleted
+}
+
+// printStats writes summaries like "git diff --stat", "--shortstat" or "--numstat" to w
+func printStats(w io.Writer, summaries []fileSummary) {
+	if len(summaries) == 0 {
+		return
+	}
+	if *numStat {
+		for _, s := range summaries {
+			if s.binary {
+				fmt.Fprintf(w, "-\t-\t%s\n", s.statPath())
+			} else {
+				fmt.Fprintf(w, "%d\t%d\t%s\n", s.inserted, s.deleted, s.statPath())
+			}
+		}
+		return
+	}
+	if *shortStat {
+		fmt.Fprintln(w, shortStatLine(summaries))
+		return
+	}
+	nameWidth, maxChange, numberWidth := 0, 0, 0
+	for _, s := range summaries {
+		if n := runewidth.StringWidth(s.statPath()); n > nameWidth {
+			nameWidth = n
+		}
+		if s.inserted+s.deleted > maxChange {
+			maxChange = s.inserted + s.deleted
+		}
+		if s.binary {
+			numberWidth = 3
+		}
+	}
+	if n := len(strconv.Itoa(maxChange)); n > numberWidth {
+		numberWidth = n
+	}
+	statWidth := *width
+	if statWidth <= 0 {
+		statWidth = terminalWidth()
+	}
+	// " name | count graph" without the last column like git
+	graphWidth := statWidth - nameWidth - numberWidth - 6
+	if graphWidth < 10 {
+		graphWidth = 10
+	}
+	if maxChange < graphWidth {
+		graphWidth = maxChange
+	}
+	for _, s := range summaries {
+		name := runewidth.FillRight(s.statPath(), nameWidth)
+		if s.binary {
+			fmt.Fprintf(w, " %s | %*s\n", name, numberWidth, "Bin")
+			continue
+		}
+		inserted, deleted := scaleStat(s.inserted, s.deleted, graphWidth, maxChange)
+		graph := ""
+		if inserted > 0 {
+			graph += " " + color.Green.Sprint(strings.Repeat("+", inserted))
+		}
+		if deleted > 0 {
+			if graph == "" {
+				graph = " "
+			}
+			graph += color.Red.Sprint(strings.Repeat("-", deleted))
+		}
+		fmt.Fprintf(w, " %s | %*d%s\n", name, numberWidth, s.inserted+s.deleted, graph)
+	}
+	fmt.Fprintln(w, shortStatLine(summaries))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gookit/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleStat(t *testing.T) {
+	tests := []struct {
+		name         string
+		inserted     int
+		deleted      int
+		wantInserted int
+		wantDeleted  int
+	}{
+		{name: "inserted only", inserted: 200, wantInserted: 42},
+		{name: "both", inserted: 30, deleted: 10, wantInserted: 6, wantDeleted: 3},
+		{name: "small change", inserted: 1, deleted: 1, wantInserted: 1, wantDeleted: 1},
+		{name: "more deleted", inserted: 1, deleted: 30, wantInserted: 1, wantDeleted: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inserted, deleted := scaleStat(tt.inserted, tt.deleted, 42, 200)
+			assert.Equal(t, tt.wantInserted, inserted)
+			assert.Equal(t, tt.wantDeleted, deleted)
+		})
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	*width = 60
+	defer func() { *width = 0 }()
+	var builder strings.Builder
+	printStats(&builder, []fileSummary{
+		{path: "big.txt", inserted: 200},
+		{path: "mid.txt", inserted: 30, deleted: 10},
+		{path: "small.txt", inserted: 1, deleted: 1},
+	})
+	// output of "git diff --stat=60"
+	assert.Equal(t, " big.txt   | 200 ++++++++++++++++++++++++++++++++++++++++++\n"+
+		" mid.txt   |  40 ++++++---\n"+
+		" small.txt |   2 +-\n"+
+		" 3 files changed, 231 insertions(+), 11 deletions(-)\n", color.ClearCode(builder.String()))
+}
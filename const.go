@@ -13,6 +13,25 @@ const (
 	LineByLine DiffType = iota + 1
 	// WordByWord returns complex result that includes word by word diffs in line by line diffs
 	WordByWord
+	// TokenByToken is like WordByWord, but changed fragments are aligned to words split on Unicode word boundaries
+	TokenByToken
+	// CharByChar is like WordByWord, but lines are compared character by character
+	CharByChar
+)
+
+// Algorithms to find line diffs. They can be combined with LineByLine or WordByWord like WordByWord|Patience
+const (
+	// Myers uses Myers' algorithm (default)
+	Myers DiffType = iota << 4
+	// Patience uses patience diff algorithm. It uses unique lines as anchors and produces readable hunks for source code
+	Patience
+	// Histogram uses histogram diff algorithm like git. It is an extension of patience diff that supports low-occurrence lines
+	Histogram
+)
+
+const (
+	granularityMask DiffType = 0x0f
+	algorithmMask   DiffType = 0xf0
 )
 
 // Ope defines the operation of a diff item.
@@ -61,6 +80,15 @@ const (
 	CloseSection
 	OpenHeader
 	CloseHeader
+	// OpenMovedDeleted is used for deleted lines that are moved to another place. OpenDeletedNotModified is used if it is not in a theme.
+	OpenMovedDeleted
+	CloseMovedDeleted
+	// OpenMovedInserted is used for inserted lines that are moved from another place. OpenInsertedNotModified is used if it is not in a theme.
+	OpenMovedInserted
+	CloseMovedInserted
+	// CarriageReturn is written instead of carriage return at the end of changed lines whose line ending differs from the other side.
+	// Themes without it write carriage returns of lines as they are, so the output keeps line endings.
+	CarriageReturn
 )
 
 // GooKitColorTag is a theme for Result.Format() method for coloring console
@@ -83,6 +111,20 @@ var GooKitColorTag = map[Tag]string{
 	CloseSection:             "</>\n",
 	OpenHeader:               "",
 	CloseHeader:              "\n",
+	OpenMovedDeleted:         "<fg=magenta;op=bold>",
+	CloseMovedDeleted:        "</>",
+	OpenMovedInserted:        "<fg=cyan;op=bold>",
+	CloseMovedInserted:       "</>",
+	CarriageReturn:           CarriageReturnMarker,
+}
+
+// PlainTag is a theme for Result.Format() method for plain text. Its unified output can be read by Patch()
+var PlainTag = map[Tag]string{
+	CloseDeletedLine:  "\n",
+	CloseInsertedLine: "\n",
+	CloseKeepLine:     "\n",
+	CloseSection:      "\n",
+	CloseHeader:       "\n",
 }
 
 // GooKitColorTheme is a theme for Result.Format() method for coloring console
@@ -95,10 +137,14 @@ var GooKitColorTheme = map[Tag]color.Style{
 	OpenInsertedNotModified: color.New(color.Green),
 	OpenKeepLine:            nil,
 	OpenSection:             color.New(color.Cyan),
+	OpenMovedDeleted:        color.New(color.Magenta, color.OpBold),
+	OpenMovedInserted:       color.New(color.Cyan, color.OpBold),
 	OpenHeader:              nil,
 }
 
-// HTMLTheme is a theme for Result.Format() method for generating HTML
+// HTMLTag is a theme for Result.Format() method for generating HTML
+//
+// Texts are not escaped. Use HTMLRenderer for texts that may have "<" or "&".
 var HTMLTag = map[Tag]string{
 	OpenDeletedLine:          `<div style="background-color: #ffecec;">`,
 	CloseDeletedLine:         `</div>`,
@@ -114,4 +160,12 @@ var HTMLTag = map[Tag]string{
 	CloseInsertedNotModified: "",
 	OpenKeepLine:             `<div style="background-color: #ffffff;">`,
 	CloseKeepLine:            "</div>",
+	OpenSection:              `<div style="background-color: #f1f8ff;">`,
+	CloseSection:             "</div>",
+	OpenHeader:               `<div style="font-weight: bold;">`,
+	CloseHeader:              "</div>",
+	OpenMovedDeleted:         `<span style="color: #a0309f;">`,
+	CloseMovedDeleted:        "</span>",
+	OpenMovedInserted:        `<span style="color: #1f7f9f;">`,
+	CloseMovedInserted:       "</span>",
 }
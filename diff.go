@@ -2,8 +2,7 @@ package cdiff
 
 import (
 	"strings"
-
-	"github.com/sergi/go-diff/diffmatchpatch"
+	"unicode"
 )
 
 type blockDiff struct {
@@ -11,54 +10,112 @@ type blockDiff struct {
 	Text          string
 	NewLineNumber int
 	OldLineNumber int
+	// LFOnly is true if lines are separated by "\n" only like hunks of unified diff. Lone "\r" is a part of a line.
+	LFOnly bool
+	// NewText is the text of the new side of a Keep block. It differs from Text if differences are ignored by Options.
+	NewText string
 }
 
-func calcBlockDiff(oldText, newText string) []blockDiff {
-	dmp := diffmatchpatch.New()
-	a, b, c := dmp.DiffLinesToChars(oldText, newText)
-	diffs := dmp.DiffMain(a, b, true)
-	diffByLines := dmp.DiffCharsToLines(diffs, c)
-	result := make([]blockDiff, len(diffByLines))
-	newLineNum := 1
-	oldLineNum := 1
-	for i, diff := range diffByLines {
-		f := blockDiff{
-			Ope:           Ope(diff.Type),
-			Text:          diff.Text,
-			NewLineNumber: -1,
-			OldLineNumber: -1,
-		}
-		inc := strings.Count(diff.Text, "\n")
-		switch f.Ope {
-		case Insert:
-			f.NewLineNumber = newLineNum
-			newLineNum += inc
-		case Delete:
-			f.OldLineNumber = oldLineNum
-			oldLineNum += inc
-		case Keep:
-			f.NewLineNumber = newLineNum
-			f.OldLineNumber = oldLineNum
-			newLineNum += inc
-			oldLineNum += inc
+// splitLines splits text of the block into texts and terminators of lines
+func (b blockDiff) splitLines() (lines, terminators []string) {
+	split := splitLinesWithTerminator
+	if b.LFOnly {
+		split = splitLinesAtLF
+	}
+	for _, line := range split(b.Text) {
+		text, terminator := splitTerminator(line)
+		if b.LFOnly && terminator == "\r" {
+			text, terminator = line, ""
 		}
-		result[i] = f
+		lines = append(lines, text)
+		terminators = append(terminators, terminator)
 	}
-	return result
+	return
+}
+
+func calcBlockDiff(oldText, newText string, options Options) []blockDiff {
+	return calcLineDiffs(oldText, newText, options)
 }
 
 // Fragment contains the smallest text fragment of diff
 type Fragment struct {
-	Text    string
-	Changed bool
+	Text    string `json:"text"`
+	Changed bool   `json:"changed"`
 }
 
+// Move is an annotation of lines that are moved to another place
+type Move int8
+
+const (
+	// NotMoved is a line that is not moved
+	NotMoved Move = iota
+	// MovedFrom is a deleted line that is inserted in another place
+	MovedFrom
+	// MovedTo is an inserted line that is deleted from another place
+	MovedTo
+)
+
 // Line represents a line
+//
+// Blank lines ignored by Options.IgnoreBlankLines are Keep lines that have only OldLineNumber or NewLineNumber.
 type Line struct {
-	Ope           Ope
-	NewLineNumber int
-	OldLineNumber int
-	Fragments     []Fragment
+	Ope           Ope        `json:"ope"`
+	NewLineNumber int        `json:"newLineNumber"`
+	OldLineNumber int        `json:"oldLineNumber"`
+	Fragments     []Fragment `json:"fragments"`
+	// Move is set if Options.DetectMoves is true
+	Move Move `json:"move,omitempty"`
+	// NoNewline is true if the line is the last line of the text that doesn't end with newline
+	NoNewline bool `json:"noNewline,omitempty"`
+	// Terminator is "\r\n" or "\r" if the line ends with it. It is empty for "\n" and lines with NoNewline.
+	Terminator string `json:"terminator,omitempty"`
+	// TerminatorChanged is true if the deleted or inserted line has the same text as the other side, but the terminator is different
+	TerminatorChanged bool `json:"terminatorChanged,omitempty"`
+	// NewText is the original text of the new side with its terminator
+	// if the kept line differs from it only in white spaces or line endings ignored by Options
+	NewText string `json:"newText,omitempty"`
+}
+
+// setTerminator sets Terminator and NoNewline from the terminator of the original line
+func (d *Line) setTerminator(terminator string) {
+	d.NoNewline = terminator == ""
+	if terminator != "\n" {
+		d.Terminator = terminator
+	}
+}
+
+// terminator returns the original terminator of the line
+func (d Line) terminator() string {
+	switch {
+	case d.NoNewline:
+		return ""
+	case d.Terminator != "":
+		return d.Terminator
+	}
+	return "\n"
+}
+
+// newSide returns the line as it is in the new text
+func (d Line) newSide() Line {
+	if d.NewText == "" {
+		return d
+	}
+	text, terminator := splitTerminator(d.NewText)
+	result := d
+	result.Fragments = []Fragment{{Text: text}}
+	result.Terminator = ""
+	result.setTerminator(terminator)
+	return result
+}
+
+// inOld returns true if the line exists in the old text
+func (d Line) inOld() bool {
+	return d.Ope == Delete || (d.Ope == Keep && d.OldLineNumber != -1)
+}
+
+// inNew returns true if the line exists in the new text
+func (d Line) inNew() bool {
+	return d.Ope == Insert || (d.Ope == Keep && d.NewLineNumber != -1)
 }
 
 func (d Line) String() string {
@@ -91,140 +148,189 @@ func (r Result) String() string {
 	return builder.String()
 }
 
-func lineDiff(oldText, newText string) Result {
-	var result Result
-	blocks := calcBlockDiff(oldText, newText)
-	for _, block := range blocks {
-		lines := strings.Split(block.Text, "\n")
-		for i, line := range lines[:len(lines)-1] {
-			lineObj := Line{
-				Ope: block.Ope,
-				Fragments: []Fragment{
-					{
-						Text: line,
-					},
+// noNewline returns true if the last line of the side has NoNewline
+func (r Result) noNewline(side func(l Line) bool) bool {
+	for i := len(r.Lines) - 1; i >= 0; i-- {
+		if side(r.Lines[i]) {
+			return r.Lines[i].NoNewline
+		}
+	}
+	return false
+}
+
+// OldNoNewline returns true if the old text doesn't end with newline
+func (r Result) OldNoNewline() bool {
+	return r.noNewline(Line.inOld)
+}
+
+// NewNoNewline returns true if the new text doesn't end with newline
+func (r Result) NewNoNewline() bool {
+	return r.noNewline(Line.inNew)
+}
+
+// HasChanges returns true if the result has inserted or deleted lines
+func (r Result) HasChanges() bool {
+	for _, l := range r.Lines {
+		if l.Ope != Keep {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal returns true if the texts are same. Differences ignored by Options are not counted.
+func (r Result) Equal() bool {
+	return !r.HasChanges()
+}
+
+// splitBlock splits a block into lines. If changed is true, non-keep lines are marked as changed.
+func splitBlock(block blockDiff, changed bool) []Line {
+	var result []Line
+	lines, terminators := block.splitLines()
+	var newLines []string
+	if block.NewText != "" {
+		newLines = splitLinesWithTerminator(block.NewText)
+	}
+	for i, text := range lines {
+		lineObj := Line{
+			Ope: block.Ope,
+			Fragments: []Fragment{
+				{
+					Text:    text,
+					Changed: changed && block.Ope != Keep,
 				},
-				OldLineNumber: -1,
-				NewLineNumber: -1,
-			}
-			if block.NewLineNumber > 0 {
-				lineObj.NewLineNumber = block.NewLineNumber + i
-			}
-			if block.OldLineNumber > 0 {
-				lineObj.OldLineNumber = block.OldLineNumber + i
-			}
-			result.Lines = append(result.Lines, lineObj)
+			},
+			OldLineNumber: -1,
+			NewLineNumber: -1,
+		}
+		lineObj.setTerminator(terminators[i])
+		if newLines != nil && newLines[i] != text+terminators[i] {
+			lineObj.NewText = newLines[i]
+		}
+		if block.NewLineNumber > 0 {
+			lineObj.NewLineNumber = block.NewLineNumber + i
 		}
+		if block.OldLineNumber > 0 {
+			lineObj.OldLineNumber = block.OldLineNumber + i
+		}
+		result = append(result, lineObj)
 	}
 	return result
 }
 
-func splitDiffsByNewLine(diffs []diffmatchpatch.Diff) []diffmatchpatch.Diff {
-	result := make([]diffmatchpatch.Diff, 0, len(diffs))
-	for _, diff := range diffs {
-		texts := strings.Split(diff.Text, "\n")
-		for i, text := range texts {
-			if i != len(texts)-1 {
-				text += "\n"
-			}
-			if text != "" {
-				result = append(result, diffmatchpatch.Diff{
-					Text: text,
-					Type: diff.Type,
-				})
-			}
-		}
+func lineDiffBlocks(blocks []blockDiff) []Line {
+	var result []Line
+	for _, block := range blocks {
+		result = append(result, splitBlock(block, false)...)
 	}
 	return result
 }
 
-func wordDiff(oldText, newText string) Result {
-	var result Result
-	blocks := calcBlockDiff(oldText, newText)
-	dmp := diffmatchpatch.New()
+func lineDiff(oldText, newText string, options Options) Result {
+	return Result{Lines: lineDiffBlocks(calcBlockDiff(oldText, newText, options))}
+}
+
+// wordDiffBlocks converts blocks into lines. Similar lines in Delete blocks followed by Insert blocks are refined word by word.
+func wordDiffBlocks(blocks []blockDiff, options Options) []Line {
+	var result []Line
 	for i := 0; i < len(blocks); i++ {
 		if i != len(blocks)-1 && blocks[i].Ope == Delete && blocks[i+1].Ope == Insert {
-			diffs := dmp.DiffMain(blocks[i].Text, blocks[i+1].Text, true)
-			diffs = splitDiffsByNewLine(diffs)
-			var fragments []Fragment
-			oldLineNumber := blocks[i].OldLineNumber
-			newLineNumber := blocks[i].NewLineNumber
-			for _, diff := range diffs {
-				if diff.Type == diffmatchpatch.DiffInsert {
-					continue
-				}
-				hasNewLine := strings.HasSuffix(diff.Text, "\n")
-				fragments = append(fragments, Fragment{
-					Changed: diff.Type == diffmatchpatch.DiffDelete,
-					Text:    strings.TrimRight(diff.Text, "\n"),
-				})
-				if hasNewLine {
-					result.Lines = append(result.Lines, Line{
-						Ope:           Delete,
-						NewLineNumber: -1,
-						OldLineNumber: oldLineNumber,
-						Fragments:     fragments,
-					})
-					fragments = nil
-					oldLineNumber++
-				}
-			}
-			fragments = nil
-			oldLineNumber = blocks[i+1].OldLineNumber
-			newLineNumber = blocks[i+1].NewLineNumber
-			for _, diff := range diffs {
-				if diff.Type == diffmatchpatch.DiffDelete {
-					continue
-				}
-				hasNewLine := strings.HasSuffix(diff.Text, "\n")
-				fragments = append(fragments, Fragment{
-					Changed: diff.Type == diffmatchpatch.DiffInsert,
-					Text:    strings.TrimRight(diff.Text, "\n"),
-				})
-				if hasNewLine {
-					result.Lines = append(result.Lines, Line{
-						Ope:           Insert,
-						NewLineNumber: newLineNumber,
-						OldLineNumber: -1,
-						Fragments:     fragments,
-					})
-					fragments = nil
-					newLineNumber++
-				}
-			}
+			result = append(result, pairChangedLines(blocks[i], blocks[i+1], options)...)
 			i++
 		} else {
-			block := blocks[i]
-			lines := strings.Split(block.Text, "\n")
-			for i, line := range lines[:len(lines)-1] {
-				lineObj := Line{
-					Ope: block.Ope,
-					Fragments: []Fragment{
-						{
-							Text:    line,
-							Changed: block.Ope != Keep,
-						},
-					},
-					OldLineNumber: -1,
-					NewLineNumber: -1,
-				}
-				if block.NewLineNumber > 0 {
-					lineObj.NewLineNumber = block.NewLineNumber + i
-				}
-				if block.OldLineNumber > 0 {
-					lineObj.OldLineNumber = block.OldLineNumber + i
-				}
-				result.Lines = append(result.Lines, lineObj)
-			}
+			result = append(result, splitBlock(blocks[i], true)...)
 		}
 	}
 	return result
 }
 
+func wordDiff(oldText, newText string, options Options) Result {
+	return Result{Lines: wordDiffBlocks(calcBlockDiff(oldText, newText, options), options)}
+}
+
+// Options is an option of DiffWithOptions()
+type Options struct {
+	// Type is LineByLine or WordByWord. It can be combined with an algorithm like WordByWord|Patience.
+	Type DiffType
+	// IgnoreAllSpace ignores all white space when comparing lines like "diff -w"
+	IgnoreAllSpace bool
+	// IgnoreSpaceChange ignores changes in the amount of white space like "diff -b"
+	IgnoreSpaceChange bool
+	// IgnoreBlankLines ignores changes whose lines are all blank like "diff --ignore-blank-lines"
+	IgnoreBlankLines bool
+	// StripTrailingCR ignores carriage return at the end of lines like "diff --strip-trailing-cr"
+	StripTrailingCR bool
+	// IgnoreLineEnding ignores differences of line terminators ("\n", "\r\n" and "\r").
+	// Missing newline at the end of text is still a difference.
+	IgnoreLineEnding bool
+	// SimilarityThreshold is the minimum similarity (0-1) to pair deleted and inserted lines for word by word diff.
+	// The default value is 0.5. Unpaired lines are marked as changed entirely.
+	SimilarityThreshold float64
+	// DetectMoves marks blocks of lines that are deleted and inserted in another place with Line.Move like "git diff --color-moved"
+	DetectMoves bool
+	// Tokenizer splits lines into tokens for word by word diff. If it is set, Type should not be LineByLine.
+	Tokenizer Tokenizer
+}
+
+// normalize returns a line for comparison. The result keeps the terminator of the line.
+func (o Options) normalize(line string) string {
+	line, eol := splitTerminator(line)
+	if (o.StripTrailingCR && eol == "\r\n") || (o.IgnoreLineEnding && eol != "") {
+		eol = "\n"
+	}
+	if o.IgnoreAllSpace {
+		line = strings.Join(strings.Fields(line), "")
+	} else if o.IgnoreSpaceChange {
+		// leading white space is still significant, but trailing white space is ignored
+		leading := strings.TrimLeftFunc(line, unicode.IsSpace) != line
+		line = strings.Join(strings.Fields(line), " ")
+		if leading && line != "" {
+			line = " " + line
+		}
+	}
+	return line + eol
+}
+
+// appendFragment appends a fragment. If white space is ignored, white space around changed text is not marked as changed.
+func (o Options) appendFragment(fragments []Fragment, text string, changed bool) []Fragment {
+	if !changed || !(o.IgnoreAllSpace || o.IgnoreSpaceChange) {
+		return append(fragments, Fragment{Text: text, Changed: changed})
+	}
+	body := strings.TrimLeftFunc(text, unicode.IsSpace)
+	if leading := text[:len(text)-len(body)]; leading != "" {
+		fragments = append(fragments, Fragment{Text: leading})
+	}
+	trimmed := strings.TrimRightFunc(body, unicode.IsSpace)
+	if trimmed != "" {
+		fragments = append(fragments, Fragment{Text: trimmed, Changed: true})
+	}
+	if trailing := body[len(trimmed):]; trailing != "" {
+		fragments = append(fragments, Fragment{Text: trailing})
+	}
+	return fragments
+}
+
 // Diff calcs diff of text
+//
+// diffType is LineByLine or WordByWord. It can be combined with an algorithm like WordByWord|Patience.
 func Diff(oldText, newText string, diffType DiffType) Result {
-	if diffType == LineByLine {
-		return lineDiff(oldText, newText)
+	return DiffWithOptions(oldText, newText, Options{Type: diffType})
+}
+
+// DiffWithOptions calcs diff of text with options
+//
+// Lines are compared after normalized by options, but Result has original texts.
+// Kept lines have the text of oldText.
+func DiffWithOptions(oldText, newText string, options Options) Result {
+	var result Result
+	if options.Type&granularityMask == LineByLine {
+		result = lineDiff(oldText, newText, options)
+	} else {
+		result = wordDiff(oldText, newText, options)
 	}
-	return wordDiff(oldText, newText)
+	markTerminatorChanges(result.Lines)
+	if options.DetectMoves {
+		detectMoves(result.Lines)
+	}
+	return result
 }
@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func dumpForTest(r Result) string {
@@ -114,7 +116,7 @@ func TestBlockDiff(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := calcBlockDiff(tt.args.src1, tt.args.src2); !reflect.DeepEqual(got, tt.want) {
+			if got := calcBlockDiff(tt.args.src1, tt.args.src2, Options{}); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("LineDiff() = %v, want %v", got, tt.want)
 			}
 		})
@@ -190,7 +192,7 @@ func TestDiff(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if got := Diff(tt.args.oldText, tt.args.newText, tt.args.diffType); dumpForTest(got) != tt.want {
-				for _, line := range calcBlockDiff(tt.want, got.String()) {
+				for _, line := range calcBlockDiff(tt.want, got.String(), Options{}) {
 					t.Log(line)
 				}
 				t.Errorf("Diff() = %v, want %v", dumpForTest(got), tt.want)
@@ -198,3 +200,145 @@ func TestDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestDiffWithOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		options Options
+		want    string
+	}{
+		{
+			name:    "no options",
+			oldText: "a b\nc\n",
+			newText: "a  b\nc\n",
+			options: Options{Type: LineByLine},
+			want:    "- a b\n+ a  b\n  c\n",
+		},
+		{
+			name:    "ignore all space",
+			oldText: "a b\nc\n",
+			newText: "ab \nc\n",
+			options: Options{Type: LineByLine, IgnoreAllSpace: true},
+			want:    "  a b\n  c\n",
+		},
+		{
+			name:    "ignore space change",
+			oldText: "a b\n  c\nd\n",
+			newText: "a \t b \n    c\nd e\n",
+			options: Options{Type: LineByLine, IgnoreSpaceChange: true},
+			want:    "  a b\n    c\n- d\n+ d e\n",
+		},
+		{
+			name:    "ignore space change: adding space is a change",
+			oldText: "ab\n",
+			newText: "a b\n",
+			options: Options{Type: LineByLine, IgnoreSpaceChange: true},
+			want:    "- ab\n+ a b\n",
+		},
+		{
+			name:    "ignore blank lines",
+			oldText: "a\nb\n",
+			newText: "a\n\n\nb\n",
+			options: Options{Type: LineByLine, IgnoreBlankLines: true},
+			want:    "  a\n  \n  \n  b\n",
+		},
+		{
+			name:    "ignore blank lines: changes with other lines",
+			oldText: "a\nb\n",
+			newText: "a\n\nc\n",
+			options: Options{Type: LineByLine, IgnoreBlankLines: true},
+			want:    "  a\n- b\n+ \n+ c\n",
+		},
+		{
+			name:    "ignore space change and line ending",
+			oldText: "a  b\r\nc\n",
+			newText: "a b\nc\n",
+			options: Options{Type: LineByLine, IgnoreSpaceChange: true, IgnoreLineEnding: true},
+			want:    "  a  b\n  c\n",
+		},
+		{
+			name:    "strip trailing cr",
+			oldText: "a\r\nb\r\n",
+			newText: "a\nc\n",
+			options: Options{Type: LineByLine, StripTrailingCR: true},
+			want:    "  a\n- b\n+ c\n",
+		},
+		{
+			name:    "white space fragments are not highlighted",
+			oldText: "a = b\n",
+			newText: "a  =  c\n",
+			options: Options{Type: WordByWord, IgnoreSpaceChange: true},
+			want:    "- a = [b]\n+ a  =  [c]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffWithOptions(tt.oldText, tt.newText, tt.options)
+			assert.Equal(t, tt.want, dumpForTest(got))
+			assert.Equal(t, tt.oldText, got.Old())
+			assert.Equal(t, tt.newText, got.New())
+		})
+	}
+}
+
+func TestResult_HasChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		options Options
+		want    bool
+	}{
+		{
+			name:    "same texts",
+			oldText: "a\nb\n",
+			newText: "a\nb\n",
+			options: Options{Type: LineByLine},
+			want:    false,
+		},
+		{
+			name:    "empty texts",
+			oldText: "",
+			newText: "",
+			options: Options{Type: WordByWord},
+			want:    false,
+		},
+		{
+			name:    "changed",
+			oldText: "a\nb\n",
+			newText: "a\nc\n",
+			options: Options{Type: WordByWord},
+			want:    true,
+		},
+		{
+			name:    "ignored changes",
+			oldText: "a b\n",
+			newText: "a  b\n\n",
+			options: Options{Type: LineByLine, IgnoreSpaceChange: true, IgnoreBlankLines: true},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffWithOptions(tt.oldText, tt.newText, tt.options)
+			assert.Equal(t, tt.want, got.HasChanges())
+			assert.Equal(t, !tt.want, got.Equal())
+		})
+	}
+}
+
+func TestIgnoreBlankLinesUnified(t *testing.T) {
+	options := Options{Type: LineByLine, IgnoreBlankLines: true}
+	// blank line changes alone are not shown
+	assert.Equal(t, "--- old\n+++ new\n", DiffWithOptions("a\nb\n", "a\n\n\nb\n", options).UnifiedWithTag("old", "new", 3, PlainTag))
+
+	// they are changes in hunks that have other changes like "diff -u -B"
+	oldText, newText := "a\nb\n", "a\n\n\nb\nc\n"
+	patch := DiffWithOptions(oldText, newText, options).UnifiedWithTag("old", "new", 3, PlainTag)
+	assert.Equal(t, "--- old\n+++ new\n@@ -1,2 +1,5 @@\n a\n+\n+\n b\n+c\n", patch)
+	result, err := Patch(oldText, patch, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, newText, result.Text)
+}
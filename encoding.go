@@ -0,0 +1,136 @@
+package cdiff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding is a name of text encoding of input
+type Encoding string
+
+// Supported encodings
+const (
+	UTF8     Encoding = "UTF-8"
+	UTF8BOM  Encoding = "UTF-8 with BOM"
+	UTF16LE  Encoding = "UTF-16LE"
+	UTF16BE  Encoding = "UTF-16BE"
+	ShiftJIS Encoding = "Shift_JIS"
+	EUCJP    Encoding = "EUC-JP"
+	Latin1   Encoding = "ISO-8859-1"
+)
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// encodingAliases are lower case names accepted by ParseEncoding()
+var encodingAliases = map[string]Encoding{
+	"utf-8":      UTF8,
+	"utf8":       UTF8,
+	"utf-8-bom":  UTF8BOM,
+	"utf8-bom":   UTF8BOM,
+	"utf-16le":   UTF16LE,
+	"utf16le":    UTF16LE,
+	"utf-16be":   UTF16BE,
+	"utf16be":    UTF16BE,
+	"shift_jis":  ShiftJIS,
+	"shift-jis":  ShiftJIS,
+	"sjis":       ShiftJIS,
+	"euc-jp":     EUCJP,
+	"eucjp":      EUCJP,
+	"iso-8859-1": Latin1,
+	"latin1":     Latin1,
+	"latin-1":    Latin1,
+}
+
+// ParseEncoding returns Encoding of name like "utf-16le", "sjis" or "latin1". Names are case insensitive.
+func ParseEncoding(name string) (Encoding, error) {
+	if e, ok := encodingAliases[strings.ToLower(name)]; ok {
+		return e, nil
+	}
+	return "", fmt.Errorf("unknown encoding: %q", name)
+}
+
+// DetectEncoding returns the encoding by the byte order mark of the content. Contents without BOM are UTF-8.
+func DetectEncoding(content []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(content, utf8BOM):
+		return UTF8BOM
+	case bytes.HasPrefix(content, utf16LEBOM):
+		return UTF16LE
+	case bytes.HasPrefix(content, utf16BEBOM):
+		return UTF16BE
+	}
+	return UTF8
+}
+
+func (e Encoding) bom() []byte {
+	switch e {
+	case UTF8BOM:
+		return utf8BOM
+	case UTF16LE:
+		return utf16LEBOM
+	case UTF16BE:
+		return utf16BEBOM
+	}
+	return nil
+}
+
+func (e Encoding) encoding() encoding.Encoding {
+	switch e {
+	case UTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case UTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	case ShiftJIS:
+		return japanese.ShiftJIS
+	case EUCJP:
+		return japanese.EUCJP
+	case Latin1:
+		return charmap.ISO8859_1
+	}
+	return encoding.Nop
+}
+
+// Decode converts the content into UTF-8 text. The byte order mark of the encoding is removed.
+// An empty encoding is detected by DetectEncoding().
+func (e Encoding) Decode(content []byte) (string, error) {
+	if e == "" {
+		e = DetectEncoding(content)
+	}
+	if bom := e.bom(); bom != nil {
+		content = bytes.TrimPrefix(content, bom)
+	}
+	result, err := e.encoding().NewDecoder().Bytes(content)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// NewDecodeReader is like Decode, but it returns a reader of UTF-8 text and the encoding for streaming.
+func NewDecodeReader(r io.Reader, e Encoding) (io.Reader, Encoding, error) {
+	reader := bufio.NewReader(r)
+	head, err := reader.Peek(len(utf8BOM))
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+	if e == "" {
+		e = DetectEncoding(head)
+	}
+	if bom := e.bom(); bom != nil && bytes.HasPrefix(head, bom) {
+		reader.Discard(len(bom))
+	}
+	return transform.NewReader(reader, e.encoding().NewDecoder()), e, nil
+}
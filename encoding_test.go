@@ -0,0 +1,76 @@
+package cdiff
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Encoding
+		wantErr bool
+	}{
+		{name: "utf-8", want: UTF8},
+		{name: "UTF-16LE", want: UTF16LE},
+		{name: "utf16be", want: UTF16BE},
+		{name: "sjis", want: ShiftJIS},
+		{name: "EUC-JP", want: EUCJP},
+		{name: "latin1", want: Latin1},
+		{name: "ebcdic", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEncoding(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEncoding_Decode(t *testing.T) {
+	tests := []struct {
+		name         string
+		encoding     Encoding
+		content      []byte
+		want         string
+		wantDetected Encoding
+	}{
+		{name: "utf-8", content: []byte("こんにちは\n"), want: "こんにちは\n", wantDetected: UTF8},
+		{name: "utf-8 with bom", content: []byte("\xef\xbb\xbfabc\n"), want: "abc\n", wantDetected: UTF8BOM},
+		{name: "utf-16le with bom", content: []byte{0xff, 0xfe, 0x42, 0x30, '\n', 0}, want: "あ\n", wantDetected: UTF16LE},
+		{name: "utf-16be with bom", content: []byte{0xfe, 0xff, 0x30, 0x42, 0, '\n'}, want: "あ\n", wantDetected: UTF16BE},
+		{name: "utf-16le without bom", encoding: UTF16LE, content: []byte{0x42, 0x30, '\n', 0}, want: "あ\n"},
+		{name: "shift_jis", encoding: ShiftJIS, content: []byte{0x82, 0xa0, '\n'}, want: "あ\n"},
+		{name: "euc-jp", encoding: EUCJP, content: []byte{0xa4, 0xa2, '\n'}, want: "あ\n"},
+		{name: "latin-1", encoding: Latin1, content: []byte("caf\xe9\n"), want: "café\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.encoding == "" {
+				assert.Equal(t, tt.wantDetected, DetectEncoding(tt.content))
+			}
+			got, err := tt.encoding.Decode(tt.content)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			r, detected, err := NewDecodeReader(bytes.NewReader(tt.content), tt.encoding)
+			assert.NoError(t, err)
+			if tt.encoding == "" {
+				assert.Equal(t, tt.wantDetected, detected)
+			} else {
+				assert.Equal(t, tt.encoding, detected)
+			}
+			streamed, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(streamed))
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package cdiff
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gookit/color"
+)
+
+// escapePattern matches ANSI escape sequences of colored "git diff" output
+var escapePattern = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// unifiedFilter re-renders unified diff text line by line
+type unifiedFilter struct {
+	w        io.Writer
+	diffType DiffType
+	renderer Renderer
+	hunk     *hunkParser
+	// markers are "\ No newline at end of file" lines after the nth line of the hunk
+	markers map[int]string
+	count   int
+}
+
+func (f *unifiedFilter) header(line string) error {
+	return f.renderer.BeginFile(f.w, FileHeader{Headers: []string{line}})
+}
+
+func (f *unifiedFilter) writeMarker(index int) error {
+	if marker, ok := f.markers[index]; ok {
+		return f.header(marker)
+	}
+	return nil
+}
+
+func (f *unifiedFilter) closeHunk() error {
+	var lines []Line
+	if f.diffType&granularityMask == LineByLine {
+		lines = lineDiffBlocks(f.hunk.blocks)
+	} else {
+		lines = wordDiffBlocks(f.hunk.blocks, Options{Type: f.diffType})
+	}
+	f.hunk = nil
+	if err := f.writeMarker(0); err != nil {
+		return err
+	}
+	for i := range lines {
+		if err := renderLines(f.w, lines[i:i+1], f.renderer); err != nil {
+			return err
+		}
+		if err := f.writeMarker(i + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *unifiedFilter) add(line string) error {
+	if f.hunk != nil {
+		switch {
+		case strings.HasPrefix(line, `\`):
+			f.markers[f.count] = line
+			return nil
+		case line == "" || line[0] == ' ':
+			f.hunk.add(Keep, strings.TrimPrefix(line, " "))
+		case line[0] == '-':
+			f.hunk.add(Delete, line[1:])
+		case line[0] == '+':
+			f.hunk.add(Insert, line[1:])
+		default:
+			// broken hunk: the rest lines are written as they are
+			if err := f.closeHunk(); err != nil {
+				return err
+			}
+			return f.header(line)
+		}
+		f.count++
+		return nil
+	}
+	if f.markers != nil && strings.HasPrefix(line, `\`) {
+		return f.header(line)
+	}
+	oldStart, oldCount, newStart, newCount, ok := parseHunkHeader(line)
+	if !ok {
+		return f.header(line)
+	}
+	if err := f.renderer.Hunk(f.w, Hunk{Header: line}); err != nil {
+		return err
+	}
+	if oldCount == 0 {
+		oldStart++
+	}
+	if newCount == 0 {
+		newStart++
+	}
+	f.hunk = &hunkParser{
+		oldLineNumber: oldStart,
+		newLineNumber: newStart,
+		oldRemaining:  oldCount,
+		newRemaining:  newCount,
+	}
+	f.markers = make(map[int]string)
+	f.count = 0
+	return nil
+}
+
+// FilterUnified reads unified diff text like output of "git diff" from r and writes it with theme.
+//
+// Unlike ParseUnified(), it keeps every line of the input as is. Headers and hunk headers are not rebuilt,
+// so the output has the same lines as the input. Lines in hunks are refined by diffType like Diff().
+// ANSI escape sequences of colored input are removed.
+// It can be used as "interactive.diffFilter" or "core.pager" of git.
+func FilterUnified(w io.Writer, r io.Reader, diffType DiffType, theme map[Tag]string) error {
+	return filterUnified(w, r, diffType, NewTagRenderer(theme))
+}
+
+// FilterUnifiedWithGooKitColor is like FilterUnified, but it colors lines by gookit/color styles
+func FilterUnifiedWithGooKitColor(w io.Writer, r io.Reader, diffType DiffType, theme map[Tag]color.Style) error {
+	return filterUnified(w, r, diffType, NewGooKitColorRenderer(theme))
+}
+
+func filterUnified(w io.Writer, r io.Reader, diffType DiffType, renderer Renderer) error {
+	f := &unifiedFilter{
+		w:        w,
+		diffType: diffType,
+		renderer: renderer,
+	}
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if line != "" {
+			line = escapePattern.ReplaceAllString(strings.TrimSuffix(line, "\n"), "")
+			if e := f.add(line); e != nil {
+				return e
+			}
+			if f.hunk != nil && f.hunk.done() {
+				if e := f.closeHunk(); e != nil {
+					return e
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if f.hunk != nil {
+		return f.closeHunk()
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package cdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gookit/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterUnified(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "git diff",
+			input: gitDiff,
+			want: `diff --git a/hello.txt b/hello.txt
+index 1234567..89abcde 100644
+--- a/hello.txt
++++ b/hello.txt
+@@ -1,3 +1,3 @@
+     abc
+-    de[f]
++    de[g]
+     ghi
+@@ -10,2 +10,3 @@ func main() {
+ jkl
++[--- not a header]
+ mno
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/new.txt	2020-01-02 03:04:05.000000000 +0900
+@@ -0,0 +1 @@
++[no newline]
+\ No newline at end of file
+`,
+		},
+		{
+			name:  "colored input",
+			input: "\x1b[1mdiff --git a/a b/a\x1b[m\n\x1b[36m@@ -1 +1 @@\x1b[m\n\x1b[31m-a b\x1b[m\n\x1b[32m+a c\x1b[m\n",
+			want:  "diff --git a/a b/a\n@@ -1 +1 @@\n-a [b]\n+a [c]\n",
+		},
+		{
+			name:  "no newline marker in the middle of hunk",
+			input: "@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+b\n",
+			want:  "@@ -1 +1 @@\n-[a]\n\\ No newline at end of file\n+[b]\n",
+		},
+		{
+			name:  "carriage return in line",
+			input: "@@ -1,2 +1,2 @@\n-a\rb\n+a\rc\n k\n",
+			want:  "@@ -1,2 +1,2 @@\n-a\r[b]\n+a\r[c]\n k\n",
+		},
+		{
+			name:  "crlf",
+			input: "@@ -1,2 +1,2 @@\n-a\r\n+b\r\n k\r\n",
+			want:  "@@ -1,2 +1,2 @@\n-[a]\r\n+[b]\r\n k\r\n",
+		},
+		{
+			name:  "broken hunk",
+			input: "@@ -1,3 +1,3 @@\n-a\n+b\ntext\n",
+			want:  "@@ -1,3 +1,3 @@\n-[a]\n+[b]\ntext\n",
+		},
+	}
+	theme := map[Tag]string{
+		OpenDeletedModified:   "[",
+		CloseDeletedModified:  "]",
+		OpenInsertedModified:  "[",
+		CloseInsertedModified: "]",
+		CloseDeletedLine:      "\n",
+		CloseInsertedLine:     "\n",
+		CloseKeepLine:         "\n",
+		CloseSection:          "\n",
+		CloseHeader:           "\n",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var builder strings.Builder
+			err := FilterUnified(&builder, strings.NewReader(tt.input), WordByWord, theme)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, builder.String())
+			assert.Equal(t, strings.Count(tt.input, "\n"), strings.Count(color.ClearTag(builder.String()), "\n"))
+		})
+	}
+}
+
+func TestFilterUnifiedWithGooKitColor(t *testing.T) {
+	input := "--- a\n+++ b\n@@ -1,2 +1,2 @@\n-a </> b\n+a </> c\n <red>x</>\n"
+	var builder strings.Builder
+	err := FilterUnifiedWithGooKitColor(&builder, strings.NewReader(input), WordByWord, GooKitColorTheme)
+	assert.Nil(t, err)
+	assert.Equal(t, input, color.ClearCode(builder.String()))
+}
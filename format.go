@@ -1,157 +1,101 @@
 package cdiff
 
 import (
-	"github.com/gookit/color"
+	"io"
 	"strconv"
 	"strings"
+
+	"github.com/gookit/color"
 )
 
-func formatWithTag(lines []Line, builder *strings.Builder, theme map[Tag]string) {
-	for _, l := range lines {
-		switch l.Ope {
-		case Insert:
-			builder.WriteString(theme[OpenInsertedLine])
-			builder.WriteString(theme[OpenInsertedNotModified])
-			builder.WriteString("+")
-			builder.WriteString(theme[CloseInsertedNotModified])
-			for _, f := range l.Fragments {
-				if f.Changed {
-					builder.WriteString(theme[OpenInsertedModified])
-					builder.WriteString(f.Text)
-					builder.WriteString(theme[CloseInsertedModified])
-				} else {
-					builder.WriteString(theme[OpenInsertedNotModified])
-					builder.WriteString(f.Text)
-					builder.WriteString(theme[CloseInsertedNotModified])
-				}
-			}
-			builder.WriteString(theme[CloseInsertedLine])
-		case Delete:
-			builder.WriteString(theme[OpenDeletedLine])
-			builder.WriteString(theme[OpenDeletedNotModified])
-			builder.WriteString("-")
-			builder.WriteString(theme[CloseDeletedNotModified])
-			for _, f := range l.Fragments {
-				if f.Changed {
-					builder.WriteString(theme[OpenDeletedModified])
-					builder.WriteString(f.Text)
-					builder.WriteString(theme[CloseDeletedModified])
-				} else {
-					builder.WriteString(theme[OpenDeletedNotModified])
-					builder.WriteString(f.Text)
-					builder.WriteString(theme[CloseDeletedNotModified])
-				}
-			}
-			builder.WriteString(theme[CloseDeletedLine])
-		case Keep:
-			builder.WriteString(theme[OpenKeepLine] + " ")
-			for _, f := range l.Fragments {
-				builder.WriteString(f.Text)
-			}
-			builder.WriteString(theme[CloseKeepLine])
+// painter decorates text with the style of the given open tag
+type painter func(tag Tag, text string) string
+
+func tagPainter(theme map[Tag]string) painter {
+	return func(tag Tag, text string) string {
+		if tag == 0 {
+			return text
 		}
+		return theme[tag] + text + theme[tag+1]
 	}
 }
 
-func formatWithTheme(lines []Line, builder *strings.Builder, theme map[Tag]color.Style) {
-	for _, l := range lines {
-		switch l.Ope {
-		case Insert:
-			builder.WriteString(theme[OpenInsertedNotModified].Sprint("+"))
-			for _, f := range l.Fragments {
-				if f.Changed {
-					builder.WriteString(theme[OpenInsertedModified].Sprint(f.Text))
-				} else {
-					builder.WriteString(theme[OpenInsertedNotModified].Sprint(f.Text))
-				}
-			}
-		case Delete:
-			builder.WriteString(theme[OpenDeletedNotModified].Sprint("-"))
-			for _, f := range l.Fragments {
-				if f.Changed {
-					builder.WriteString(theme[OpenDeletedModified].Sprint(f.Text))
-				} else {
-					builder.WriteString(theme[OpenDeletedNotModified].Sprint(f.Text))
-				}
-			}
-		case Keep:
-			builder.WriteString(" ")
-			for _, f := range l.Fragments {
-				builder.WriteString(f.Text)
-			}
+func stylePainter(theme map[Tag]color.Style) painter {
+	return func(tag Tag, text string) string {
+		if tag == 0 {
+			return text
 		}
-		builder.WriteString("\n")
+		return theme[tag].Sprint(text)
 	}
 }
 
 // Format returns formatted text
 func (r Result) Format(theme map[Tag]string) string {
 	var builder strings.Builder
-	formatWithTag(r.Lines, &builder, theme)
+	r.FormatTo(&builder, theme)
 	return builder.String()
 }
 
+// FormatTo writes formatted text to w. It returns the first error of w.
+func (r Result) FormatTo(w io.Writer, theme map[Tag]string) error {
+	return renderLines(w, r.Lines, NewTagRenderer(theme))
+}
+
 type block struct {
 	start int
 	end   int
 }
 
+// section returns a hunk header like "@@ -1,3 +1,4 @@". An empty range has count 0 and starts at the line before the hunk.
 func (b block) section(lines []Line) string {
-	minRemoved := 0
-	minInserted := 0
-	getValue := func(orig, newValue int) int {
-		if orig != 0 {
-			return orig
-		}
-		return newValue
-	}
-	for i := b.start; i <= b.end; i++ {
-		switch lines[i].Ope {
-		case Insert:
-			minInserted = getValue(minInserted, lines[i].NewLineNumber)
-			if minRemoved != 0 {
-				break
+	render := func(inSide func(l Line) bool, number func(l Line) int) string {
+		start, count := 0, 0
+		for _, l := range lines[b.start : b.end+1] {
+			if inSide(l) {
+				if count == 0 {
+					start = number(l)
+				}
+				count++
 			}
-		case Delete:
-			minRemoved = getValue(minRemoved, lines[i].OldLineNumber)
-			if minInserted != 0 {
-				break
+		}
+		if count == 0 {
+			for i := b.start - 1; i >= 0; i-- {
+				if inSide(lines[i]) {
+					start = number(lines[i])
+					break
+				}
 			}
-		case Keep:
-			minRemoved = getValue(minRemoved, lines[i].OldLineNumber)
-			minInserted = getValue(minInserted, lines[i].NewLineNumber)
-			break
 		}
+		if count == 1 {
+			return strconv.Itoa(start)
+		}
+		return strconv.Itoa(start) + "," + strconv.Itoa(count)
 	}
+	return "@@ -" + render(Line.inOld, func(l Line) int { return l.OldLineNumber }) +
+		" +" + render(Line.inNew, func(l Line) int { return l.NewLineNumber }) + " @@"
+}
 
-	maxRemoved := 0
-	maxInserted := 0
-	for i := b.end; i >= b.start; i-- {
-		switch lines[i].Ope {
-		case Insert:
-			maxInserted = getValue(maxInserted, lines[i].NewLineNumber)
-			if maxRemoved != 0 {
-				break
-			}
-		case Delete:
-			maxRemoved = getValue(maxRemoved, lines[i].OldLineNumber)
-			if minInserted != 0 {
-				break
-			}
-		case Keep:
-			maxRemoved = getValue(maxRemoved, lines[i].OldLineNumber)
-			maxInserted = getValue(maxInserted, lines[i].NewLineNumber)
-			break
+// contextStart returns the index of the first line of n kept lines before lines[i].
+// Blank line changes ignored by Options.IgnoreBlankLines are not counted.
+func contextStart(lines []Line, i, n int) int {
+	for i > 0 && n > 0 {
+		i--
+		if lines[i].inOld() && lines[i].inNew() {
+			n--
 		}
 	}
-	render := func(min, max int) string {
-		start := strconv.FormatInt(int64(min), 10)
-		if max == min {
-			return start
+	return i
+}
+
+// contextEnd returns the index of the last line of n kept lines after lines[i]
+func contextEnd(lines []Line, i, n int) int {
+	for i < len(lines)-1 && n > 0 {
+		i++
+		if lines[i].inOld() && lines[i].inNew() {
+			n--
 		}
-		return start + "," + strconv.FormatInt(int64(max-min+1), 10)
 	}
-	return "@@ -" + render(minRemoved, maxRemoved) + " +" + render(minInserted, maxInserted) + " @@"
+	return i
 }
 
 func grouping(lines []Line, extraLine int) []block {
@@ -160,7 +104,7 @@ func grouping(lines []Line, extraLine int) []block {
 	for i, l := range lines {
 		if blockStart > -1 {
 			if l.Ope == Keep {
-				blocks = append(blocks, block{start: blockStart - extraLine, end: i - 1 + extraLine})
+				blocks = append(blocks, block{start: contextStart(lines, blockStart, extraLine), end: contextEnd(lines, i-1, extraLine)})
 				blockStart = -1
 			}
 		} else {
@@ -170,15 +114,7 @@ func grouping(lines []Line, extraLine int) []block {
 		}
 	}
 	if blockStart > -1 {
-		blocks = append(blocks, block{start: blockStart - extraLine, end: len(lines) - 1 + extraLine})
-	}
-	if len(blocks) > 0 {
-		if blocks[0].start < 0 {
-			blocks[0].start = 0
-		}
-		if blocks[len(blocks)-1].end >= len(lines) {
-			blocks[len(blocks)-1].end = len(lines) - 1
-		}
+		blocks = append(blocks, block{start: contextStart(lines, blockStart, extraLine), end: len(lines) - 1})
 	}
 	result := make([]block, 0, len(blocks))
 	for i, block := range blocks {
@@ -192,37 +128,70 @@ func grouping(lines []Line, extraLine int) []block {
 			}
 		}
 	}
+	return splitDiscontinuous(lines, result)
+}
+
+// splitDiscontinuous splits blocks where line numbers jump like boundaries of hunks in parsed unified diff.
+// Split blocks that don't contain any changes are removed.
+func splitDiscontinuous(lines []Line, blocks []block) []block {
+	result := make([]block, 0, len(blocks))
+	appendIfChanged := func(b block) {
+		for i := b.start; i <= b.end; i++ {
+			if lines[i].Ope != Keep {
+				result = append(result, b)
+				return
+			}
+		}
+	}
+	for _, b := range blocks {
+		start := b.start
+		lastOld, lastNew := -1, -1
+		for i := b.start; i <= b.end; i++ {
+			l := lines[i]
+			if (l.OldLineNumber > 0 && lastOld > 0 && l.OldLineNumber != lastOld+1) ||
+				(l.NewLineNumber > 0 && lastNew > 0 && l.NewLineNumber != lastNew+1) {
+				appendIfChanged(block{start: start, end: i - 1})
+				start = i
+			}
+			if l.OldLineNumber > 0 {
+				lastOld = l.OldLineNumber
+			}
+			if l.NewLineNumber > 0 {
+				lastNew = l.NewLineNumber
+			}
+		}
+		appendIfChanged(block{start: start, end: b.end})
+	}
 	return result
 }
 
-// Unified returns unified formatWithTag diff text
+// UnifiedWithTag returns unified formatted diff text
 func (r Result) UnifiedWithTag(oldTitle, newTitle string, l int, theme map[Tag]string) string {
 	var builder strings.Builder
-	builder.WriteString(theme[OpenHeader])
-	builder.WriteString("--- " + oldTitle)
-	builder.WriteString(theme[CloseHeader])
-	builder.WriteString(theme[OpenHeader])
-	builder.WriteString("+++ " + newTitle)
-	builder.WriteString(theme[CloseHeader])
-	blocks := grouping(r.Lines, l)
-	for _, block := range blocks {
-		builder.WriteString(theme[OpenSection])
-		builder.WriteString(block.section(r.Lines))
-		builder.WriteString(theme[CloseSection])
-		lines := r.Lines[block.start : block.end+1]
-		formatWithTag(lines, &builder, theme)
-	}
+	r.UnifiedTo(&builder, oldTitle, newTitle, l, theme)
+	return builder.String()
+}
+
+// UnifiedWithHeader is like UnifiedWithTag, but it writes header that has timestamps, modes and hashes
+func (r Result) UnifiedWithHeader(header FileHeader, l int, theme map[Tag]string) string {
+	var builder strings.Builder
+	r.RenderWithHeader(&builder, header, l, NewTagRenderer(theme))
 	return builder.String()
 }
 
+// UnifiedTo writes unified formatted diff text to w. It returns the first error of w.
+func (r Result) UnifiedTo(w io.Writer, oldTitle, newTitle string, l int, theme map[Tag]string) error {
+	return r.Render(w, oldTitle, newTitle, l, NewTagRenderer(theme))
+}
+
+// UnifiedWithGooKitColor returns unified formatted diff text colored by gookit/color styles
 func (r Result) UnifiedWithGooKitColor(oldTitle, newTitle string, l int, theme map[Tag]color.Style) string {
 	var builder strings.Builder
-	builder.WriteString(theme[OpenHeader].Sprint("--- " + oldTitle + "\n+++ " + newTitle + "\n"))
-	blocks := grouping(r.Lines, l)
-	for _, block := range blocks {
-		builder.WriteString(theme[OpenSection].Sprint(block.section(r.Lines)))
-		lines := r.Lines[block.start : block.end+1]
-		formatWithTheme(lines, &builder, theme)
-	}
+	r.UnifiedWithGooKitColorTo(&builder, oldTitle, newTitle, l, theme)
 	return builder.String()
 }
+
+// UnifiedWithGooKitColorTo writes unified formatted diff text colored by gookit/color styles to w. It returns the first error of w.
+func (r Result) UnifiedWithGooKitColorTo(w io.Writer, oldTitle, newTitle string, l int, theme map[Tag]color.Style) error {
+	return r.Render(w, oldTitle, newTitle, l, NewGooKitColorRenderer(theme))
+}
@@ -1,6 +1,8 @@
 package cdiff
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/gookit/color"
@@ -131,3 +133,88 @@ func TestUnified(t *testing.T) {
 	result := color.ClearTag(diff.UnifiedWithTag("olddoc", "newdoc", 1, GooKitColorTag))
 	assert.Equal(t, expectedResult, result)
 }
+
+type failingWriter struct {
+	count int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.count == 0 {
+		return 0, errors.New("disk full")
+	}
+	f.count--
+	return len(p), nil
+}
+
+func TestUnifiedTo(t *testing.T) {
+	diff := Diff(src1, src2, WordByWord)
+	var builder strings.Builder
+	assert.Nil(t, diff.UnifiedTo(&builder, "olddoc", "newdoc", 1, GooKitColorTag))
+	assert.Equal(t, diff.UnifiedWithTag("olddoc", "newdoc", 1, GooKitColorTag), builder.String())
+
+	err := diff.UnifiedTo(&failingWriter{count: 3}, "olddoc", "newdoc", 1, GooKitColorTag)
+	assert.EqualError(t, err, "disk full")
+	err = diff.FormatTo(&failingWriter{}, HTMLTag)
+	assert.EqualError(t, err, "disk full")
+}
+
+func TestUnifiedWithGooKitColor(t *testing.T) {
+	diff := Diff(src1, src2, WordByWord)
+	result := color.ClearCode(diff.UnifiedWithGooKitColor("olddoc", "newdoc", 1, GooKitColorTheme))
+	assert.Equal(t, expectedResult, result)
+
+	var builder strings.Builder
+	assert.Nil(t, diff.UnifiedWithGooKitColorTo(&builder, "olddoc", "newdoc", 1, GooKitColorTheme))
+	assert.Equal(t, diff.UnifiedWithGooKitColor("olddoc", "newdoc", 1, GooKitColorTheme), builder.String())
+}
+
+func TestUnifiedNoNewline(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		want    string
+	}{
+		{
+			name:    "both have newline",
+			oldText: "a\nb\n",
+			newText: "a\nc\n",
+			want:    "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+		{
+			name:    "old doesn't have newline",
+			oldText: "a\nb",
+			newText: "a\nb\n",
+			want:    "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name:    "new doesn't have newline",
+			oldText: "a\nb\n",
+			newText: "a\nc",
+			want:    "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			name:    "both don't have newline",
+			oldText: "a\nb",
+			newText: "c\nb",
+			want:    "--- old\n+++ new\n@@ -1,2 +1,2 @@\n-a\n+c\n b\n\\ No newline at end of file\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, diffType := range []DiffType{LineByLine, WordByWord} {
+				diff := Diff(tt.oldText, tt.newText, diffType)
+				assert.Equal(t, tt.want, color.ClearTag(diff.UnifiedWithTag("old", "new", 1, GooKitColorTag)))
+				assert.Equal(t, tt.want, color.ClearCode(diff.UnifiedWithGooKitColor("old", "new", 1, GooKitColorTheme)))
+				assert.Equal(t, tt.oldText, diff.Old())
+				assert.Equal(t, tt.newText, diff.New())
+				assert.Equal(t, !strings.HasSuffix(tt.oldText, "\n"), diff.OldNoNewline())
+				assert.Equal(t, !strings.HasSuffix(tt.newText, "\n"), diff.NewNoNewline())
+
+				patched, err := Patch(tt.oldText, tt.want, 0)
+				assert.Nil(t, err)
+				assert.Equal(t, tt.newText, patched.Text)
+			}
+		})
+	}
+}
@@ -6,7 +6,10 @@ require (
 	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751 // indirect
 	github.com/alecthomas/units v0.0.0-20190924025748-f65c72e2690d // indirect
 	github.com/gookit/color v1.2.0
+	github.com/mattn/go-runewidth v0.0.9
+	github.com/rivo/uniseg v0.4.7
 	github.com/sergi/go-diff v1.0.0
 	github.com/stretchr/testify v1.4.0
+	golang.org/x/text v0.13.0
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
 )
@@ -0,0 +1,142 @@
+package cdiff
+
+import (
+	"github.com/rivo/uniseg"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// graphemeClusters returns byte offsets of grapheme cluster boundaries of text including 0 and len(text)
+func graphemeClusters(text string) []int {
+	result := []int{0}
+	state := -1
+	offset := 0
+	for rest := text; rest != ""; {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		offset += len(cluster)
+		result = append(result, offset)
+	}
+	return result
+}
+
+// expandToClusters marks whole grapheme clusters that have marked bytes. It returns true if a mark is added.
+func expandToClusters(changed []bool, boundaries []int) bool {
+	added := false
+	for i := 0; i < len(boundaries)-1; i++ {
+		cluster := changed[boundaries[i]:boundaries[i+1]]
+		partial := false
+		for _, c := range cluster {
+			if c {
+				partial = true
+				break
+			}
+		}
+		if !partial {
+			continue
+		}
+		for j, c := range cluster {
+			if !c {
+				cluster[j] = true
+				added = true
+			}
+		}
+	}
+	return added
+}
+
+// snapToGraphemes moves boundaries of diffs to grapheme cluster boundaries of both texts.
+// Equal text in a cluster that is changed partially, like a base character before a changed combining mark
+// or a part of an emoji ZWJ sequence, becomes deleted and inserted text.
+func snapToGraphemes(diffs []diffmatchpatch.Diff, oldText, newText string) []diffmatchpatch.Diff {
+	oldChanged := make([]bool, len(oldText))
+	newChanged := make([]bool, len(newText))
+	// equalPairs[i] is the offset in newText of the equal byte at oldText[i], or -1
+	equalPairs := make([]int, len(oldText))
+	oldOffset, newOffset := 0, 0
+	for _, diff := range diffs {
+		for i := 0; i < len(diff.Text); i++ {
+			switch diff.Type {
+			case diffmatchpatch.DiffDelete:
+				oldChanged[oldOffset+i] = true
+				equalPairs[oldOffset+i] = -1
+			case diffmatchpatch.DiffInsert:
+				newChanged[newOffset+i] = true
+			default:
+				equalPairs[oldOffset+i] = newOffset + i
+			}
+		}
+		if diff.Type != diffmatchpatch.DiffInsert {
+			oldOffset += len(diff.Text)
+		}
+		if diff.Type != diffmatchpatch.DiffDelete {
+			newOffset += len(diff.Text)
+		}
+	}
+	if oldOffset != len(oldText) || newOffset != len(newText) {
+		// diffs are not for these texts
+		return diffs
+	}
+	oldClusters := graphemeClusters(oldText)
+	newClusters := graphemeClusters(newText)
+	for {
+		added := expandToClusters(oldChanged, oldClusters)
+		if expandToClusters(newChanged, newClusters) {
+			added = true
+		}
+		for i, j := range equalPairs {
+			if j != -1 && oldChanged[i] != newChanged[j] {
+				oldChanged[i], newChanged[j] = true, true
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	var result []diffmatchpatch.Diff
+	var deleted, inserted []byte
+	var equal []byte
+	flushChange := func() {
+		if len(deleted) > 0 {
+			result = append(result, diffmatchpatch.Diff{Type: diffmatchpatch.DiffDelete, Text: string(deleted)})
+		}
+		if len(inserted) > 0 {
+			result = append(result, diffmatchpatch.Diff{Type: diffmatchpatch.DiffInsert, Text: string(inserted)})
+		}
+		deleted, inserted = nil, nil
+	}
+	flushEqual := func() {
+		if len(equal) > 0 {
+			result = append(result, diffmatchpatch.Diff{Type: diffmatchpatch.DiffEqual, Text: string(equal)})
+		}
+		equal = nil
+	}
+	oldOffset = 0
+	for _, diff := range diffs {
+		for i := 0; i < len(diff.Text); i++ {
+			c := diff.Text[i]
+			switch {
+			case diff.Type == diffmatchpatch.DiffDelete:
+				flushEqual()
+				deleted = append(deleted, c)
+			case diff.Type == diffmatchpatch.DiffInsert:
+				flushEqual()
+				inserted = append(inserted, c)
+			case oldChanged[oldOffset+i]:
+				flushEqual()
+				deleted = append(deleted, c)
+				inserted = append(inserted, c)
+			default:
+				flushChange()
+				equal = append(equal, c)
+			}
+		}
+		if diff.Type != diffmatchpatch.DiffInsert {
+			oldOffset += len(diff.Text)
+		}
+	}
+	flushChange()
+	flushEqual()
+	return result
+}
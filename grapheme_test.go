@@ -0,0 +1,60 @@
+package cdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphemeClusterFragments(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		want    string
+	}{
+		{
+			name:    "japanese",
+			oldText: "ハンカチを買った\n",
+			newText: "ハンガチを買った\n",
+			want:    "- ハン[カ]チを買った\n+ ハン[ガ]チを買った\n",
+		},
+		{
+			name:    "japanese with combining dakuten",
+			oldText: "ハンカチを買った\n",
+			newText: "ハンカ\u3099チを買った\n",
+			want:    "- ハン[カ]チを買った\n+ ハン[カ\u3099]チを買った\n",
+		},
+		{
+			name:    "combining acute accent",
+			oldText: "cafe au lait\n",
+			newText: "cafe\u0301 au lait\n",
+			want:    "- caf[e] au lait\n+ caf[e\u0301] au lait\n",
+		},
+		{
+			name:    "emoji zwj sequence",
+			oldText: "family 👨‍👩‍👧 here\n",
+			newText: "family 👨‍👩‍👦 here\n",
+			want:    "- family [👨‍👩‍👧] here\n+ family [👨‍👩‍👦] here\n",
+		},
+		{
+			name:    "emoji skin tone",
+			oldText: "thumbs 👍🏻 up\n",
+			newText: "thumbs 👍🏽 up\n",
+			want:    "- thumbs [👍🏻] up\n+ thumbs [👍🏽] up\n",
+		},
+		{
+			name:    "flag",
+			oldText: "flag 🇯🇵 here\n",
+			newText: "flag 🇯🇲 here\n",
+			want:    "- flag [🇯🇵] here\n+ flag [🇯🇲] here\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, diffType := range []DiffType{WordByWord, CharByChar, WordByWord | Patience} {
+				assert.Equal(t, tt.want, dumpForTest(Diff(tt.oldText, tt.newText, diffType)))
+			}
+		})
+	}
+}
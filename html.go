@@ -0,0 +1,308 @@
+package cdiff
+
+import (
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// HTMLLayout is a layout of HTMLRenderer
+type HTMLLayout int
+
+const (
+	// HTMLUnified shows old and new lines in one column like unified diff
+	HTMLUnified HTMLLayout = iota
+	// HTMLSplit shows old lines and new lines side by side
+	HTMLSplit
+)
+
+// HTMLStylesheet is the default stylesheet for CSS classes of HTMLRenderer
+const HTMLStylesheet = `table.cdiff { border-collapse: collapse; width: 100%; font-family: monospace; font-size: 12px; margin-bottom: 16px; }
+table.cdiff th { text-align: left; padding: 4px 8px; background-color: #f6f8fa; border-bottom: 1px solid #d1d5da; font-weight: normal; }
+table.cdiff td { padding: 0 8px; vertical-align: top; }
+.cdiff-number { width: 1%; min-width: 40px; text-align: right; color: #959da5; user-select: none; }
+.cdiff-code { white-space: pre-wrap; word-break: break-all; }
+.cdiff-mark { user-select: none; }
+.cdiff-hunk td { background-color: #f1f8ff; color: #586069; }
+.cdiff-delete { background-color: #ffeef0; }
+.cdiff-insert { background-color: #e6ffed; }
+.cdiff-delete .cdiff-changed { background-color: #fdb8c0; }
+.cdiff-insert .cdiff-changed { background-color: #acf2bd; }
+.cdiff-empty { background-color: #fafbfc; }
+.cdiff-moved-from { background-color: #f5e6f5; }
+.cdiff-moved-to { background-color: #e3f2f7; }
+.cdiff-moved-from .cdiff-changed, .cdiff-moved-to .cdiff-changed { background-color: transparent; }
+`
+
+// HTMLOptions is an option of NewHTMLRenderer()
+type HTMLOptions struct {
+	Layout HTMLLayout
+	// Stylesheet embeds HTMLStylesheet before the first table
+	Stylesheet bool
+	// Standalone writes a complete HTML document that has HTMLStylesheet. It is used by Result.HTML() and MultiResult.HTML().
+	Standalone bool
+	// Title is the title of the standalone document
+	Title string
+}
+
+// htmlCell is a rendered line that waits for pairing in split layout
+type htmlCell struct {
+	line Line
+	code string
+}
+
+// HTMLRenderer is a Renderer that writes escaped HTML tables with line numbers.
+//
+// Parts have CSS classes like "cdiff-insert", "cdiff-delete", "cdiff-changed" and "cdiff-number".
+// Moved lines have "cdiff-moved-from" or "cdiff-moved-to" too. See HTMLStylesheet.
+// It keeps state while rendering, so don't share it between goroutines.
+type HTMLRenderer struct {
+	options      HTMLOptions
+	styleWritten bool
+	code         strings.Builder
+	deleted      []htmlCell
+	inserted     []htmlCell
+}
+
+// NewHTMLRenderer returns a HTMLRenderer
+func NewHTMLRenderer(options HTMLOptions) *HTMLRenderer {
+	return &HTMLRenderer{
+		options:      options,
+		styleWritten: options.Standalone,
+	}
+}
+
+func (h *HTMLRenderer) columns() string {
+	if h.options.Layout == HTMLSplit {
+		return "4"
+	}
+	return "3"
+}
+
+// BeginDocument writes the beginning of a standalone HTML document
+func (h *HTMLRenderer) BeginDocument(w io.Writer) error {
+	title := h.options.Title
+	if title == "" {
+		title = "cdiff"
+	}
+	_, err := io.WriteString(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>"+html.EscapeString(title)+
+		"</title>\n<style>\n"+HTMLStylesheet+"</style>\n</head>\n<body>\n")
+	return err
+}
+
+// EndDocument writes the end of a standalone HTML document
+func (h *HTMLRenderer) EndDocument(w io.Writer) error {
+	_, err := io.WriteString(w, "</body>\n</html>\n")
+	return err
+}
+
+// BeginFile writes the beginning of a table and file headers
+func (h *HTMLRenderer) BeginFile(w io.Writer, header FileHeader) error {
+	var builder strings.Builder
+	if !h.styleWritten && h.options.Stylesheet {
+		builder.WriteString("<style>\n" + HTMLStylesheet + "</style>\n")
+		h.styleWritten = true
+	}
+	if h.options.Layout == HTMLSplit {
+		builder.WriteString("<table class=\"cdiff cdiff-split\">\n<thead>\n")
+	} else {
+		builder.WriteString("<table class=\"cdiff cdiff-unified\">\n<thead>\n")
+	}
+	for _, line := range header.HeaderLines() {
+		builder.WriteString("<tr class=\"cdiff-header\"><th colspan=\"" + h.columns() + "\">" + html.EscapeString(line) + "</th></tr>\n")
+	}
+	if header.OldTitle != "" || header.NewTitle != "" {
+		oldTitle, newTitle := header.Titles()
+		if h.options.Layout == HTMLSplit {
+			builder.WriteString("<tr class=\"cdiff-title\"><th colspan=\"2\">" + html.EscapeString(oldTitle) +
+				"</th><th colspan=\"2\">" + html.EscapeString(newTitle) + "</th></tr>\n")
+		} else {
+			builder.WriteString("<tr class=\"cdiff-title\"><th colspan=\"3\">--- " + html.EscapeString(oldTitle) +
+				"<br>+++ " + html.EscapeString(newTitle) + "</th></tr>\n")
+		}
+	}
+	builder.WriteString("</thead>\n<tbody>\n")
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// Hunk writes a row of the hunk header
+func (h *HTMLRenderer) Hunk(w io.Writer, hunk Hunk) error {
+	if err := h.flush(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "<tr class=\"cdiff-hunk\"><td colspan=\""+h.columns()+"\">"+html.EscapeString(hunk.Header)+"</td></tr>\n")
+	return err
+}
+
+// Line starts a line
+func (h *HTMLRenderer) Line(w io.Writer, l Line) error {
+	h.code.Reset()
+	return nil
+}
+
+// Fragment writes an escaped fragment. Changed fragments are in "cdiff-changed" spans.
+func (h *HTMLRenderer) Fragment(w io.Writer, l Line, f Fragment) error {
+	if f.Changed && l.Ope != Keep {
+		h.code.WriteString("<span class=\"cdiff-changed\">" + html.EscapeString(f.Text) + "</span>")
+	} else {
+		h.code.WriteString(html.EscapeString(f.Text))
+	}
+	return nil
+}
+
+// EndLine writes a row. In split layout, deleted and inserted lines are kept until they are paired.
+func (h *HTMLRenderer) EndLine(w io.Writer, l Line) error {
+	cell := htmlCell{line: l, code: h.code.String()}
+	if h.options.Layout != HTMLSplit {
+		_, err := io.WriteString(w, unifiedHTMLRow(cell))
+		return err
+	}
+	switch l.Ope {
+	case Delete:
+		if len(h.inserted) > 0 {
+			if err := h.flush(w); err != nil {
+				return err
+			}
+		}
+		h.deleted = append(h.deleted, cell)
+		return nil
+	case Insert:
+		h.inserted = append(h.inserted, cell)
+		return nil
+	}
+	if err := h.flush(w); err != nil {
+		return err
+	}
+	var oldCell, newCell *htmlCell
+	if l.inOld() {
+		oldCell = &cell
+	}
+	if l.inNew() {
+		newCell = &cell
+	}
+	_, err := io.WriteString(w, splitHTMLRow(oldCell, newCell))
+	return err
+}
+
+// EndFile writes the end of the table
+func (h *HTMLRenderer) EndFile(w io.Writer, header FileHeader) error {
+	if err := h.flush(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "</tbody>\n</table>\n")
+	return err
+}
+
+// flush writes pairs of deleted and inserted lines in split layout
+func (h *HTMLRenderer) flush(w io.Writer) error {
+	var builder strings.Builder
+	for i := 0; i < len(h.deleted) || i < len(h.inserted); i++ {
+		var oldCell, newCell *htmlCell
+		if i < len(h.deleted) {
+			oldCell = &h.deleted[i]
+		}
+		if i < len(h.inserted) {
+			newCell = &h.inserted[i]
+		}
+		builder.WriteString(splitHTMLRow(oldCell, newCell))
+	}
+	h.deleted = nil
+	h.inserted = nil
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+func htmlClass(l Line) string {
+	switch {
+	case l.Ope == Insert && l.Move == MovedTo:
+		return "cdiff-insert cdiff-moved-to"
+	case l.Ope == Insert:
+		return "cdiff-insert"
+	case l.Ope == Delete && l.Move == MovedFrom:
+		return "cdiff-delete cdiff-moved-from"
+	case l.Ope == Delete:
+		return "cdiff-delete"
+	}
+	return "cdiff-keep"
+}
+
+func htmlMark(ope Ope) string {
+	switch ope {
+	case Insert:
+		return "+"
+	case Delete:
+		return "-"
+	}
+	return " "
+}
+
+func htmlLineNumber(number int) string {
+	if number <= 0 {
+		return ""
+	}
+	return strconv.Itoa(number)
+}
+
+func unifiedHTMLRow(cell htmlCell) string {
+	l := cell.line
+	var oldNumber, newNumber string
+	if l.inOld() {
+		oldNumber = htmlLineNumber(l.OldLineNumber)
+	}
+	if l.inNew() {
+		newNumber = htmlLineNumber(l.NewLineNumber)
+	}
+	return "<tr class=\"" + htmlClass(l) + "\"><td class=\"cdiff-number\">" + oldNumber +
+		"</td><td class=\"cdiff-number\">" + newNumber +
+		"</td><td class=\"cdiff-code\"><span class=\"cdiff-mark\">" + htmlMark(l.Ope) + "</span>" + cell.code + "</td></tr>\n"
+}
+
+func splitHTMLCell(cell *htmlCell, number int) string {
+	if cell == nil {
+		return "<td class=\"cdiff-number cdiff-empty\"></td><td class=\"cdiff-code cdiff-empty\"></td>"
+	}
+	class := htmlClass(cell.line)
+	return "<td class=\"cdiff-number " + class + "\">" + htmlLineNumber(number) +
+		"</td><td class=\"cdiff-code " + class + "\"><span class=\"cdiff-mark\">" + htmlMark(cell.line.Ope) + "</span>" + cell.code + "</td>"
+}
+
+func splitHTMLRow(oldCell, newCell *htmlCell) string {
+	var builder strings.Builder
+	builder.WriteString("<tr>")
+	if oldCell != nil {
+		builder.WriteString(splitHTMLCell(oldCell, oldCell.line.OldLineNumber))
+	} else {
+		builder.WriteString(splitHTMLCell(nil, 0))
+	}
+	if newCell != nil {
+		builder.WriteString(splitHTMLCell(newCell, newCell.line.NewLineNumber))
+	} else {
+		builder.WriteString(splitHTMLCell(nil, 0))
+	}
+	builder.WriteString("</tr>\n")
+	return builder.String()
+}
+
+// HTML writes diff as HTML table. If options.Standalone is true, it writes a complete HTML document.
+func (r Result) HTML(w io.Writer, oldTitle, newTitle string, l int, options HTMLOptions) error {
+	return MultiResult{Files: []FileResult{{OldName: oldTitle, NewName: newTitle, Result: r}}}.HTML(w, l, options)
+}
+
+// HTML writes diff of all files as HTML tables. If options.Standalone is true, it writes a complete HTML document.
+func (m MultiResult) HTML(w io.Writer, l int, options HTMLOptions) error {
+	renderer := NewHTMLRenderer(options)
+	if options.Standalone {
+		if err := renderer.BeginDocument(w); err != nil {
+			return err
+		}
+	}
+	if err := m.Render(w, l, renderer); err != nil {
+		return err
+	}
+	if options.Standalone {
+		return renderer.EndDocument(w)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLUnified(t *testing.T) {
+	diff := Diff("a < b\nc\n", "a > b\nc\n", WordByWord)
+	var builder strings.Builder
+	assert.Nil(t, diff.HTML(&builder, "old&.txt", "new.txt", 1, HTMLOptions{}))
+	assert.Equal(t, `<table class="cdiff cdiff-unified">
+<thead>
+<tr class="cdiff-title"><th colspan="3">--- old&amp;.txt<br>+++ new.txt</th></tr>
+</thead>
+<tbody>
+<tr class="cdiff-hunk"><td colspan="3">@@ -1,2 +1,2 @@</td></tr>
+<tr class="cdiff-delete"><td class="cdiff-number">1</td><td class="cdiff-number"></td><td class="cdiff-code"><span class="cdiff-mark">-</span>a <span class="cdiff-changed">&lt;</span> b</td></tr>
+<tr class="cdiff-insert"><td class="cdiff-number"></td><td class="cdiff-number">1</td><td class="cdiff-code"><span class="cdiff-mark">+</span>a <span class="cdiff-changed">&gt;</span> b</td></tr>
+<tr class="cdiff-keep"><td class="cdiff-number">2</td><td class="cdiff-number">2</td><td class="cdiff-code"><span class="cdiff-mark"> </span>c</td></tr>
+</tbody>
+</table>
+`, builder.String())
+}
+
+func TestHTMLSplit(t *testing.T) {
+	diff := Diff("a\nb\nc\n", "a\nB\nx\nc\n", LineByLine)
+	var builder strings.Builder
+	assert.Nil(t, diff.HTML(&builder, "old.txt", "new.txt", 0, HTMLOptions{Layout: HTMLSplit}))
+	assert.Equal(t, `<table class="cdiff cdiff-split">
+<thead>
+<tr class="cdiff-title"><th colspan="2">old.txt</th><th colspan="2">new.txt</th></tr>
+</thead>
+<tbody>
+<tr class="cdiff-hunk"><td colspan="4">@@ -2 +2,2 @@</td></tr>
+<tr><td class="cdiff-number cdiff-delete">2</td><td class="cdiff-code cdiff-delete"><span class="cdiff-mark">-</span>b</td><td class="cdiff-number cdiff-insert">2</td><td class="cdiff-code cdiff-insert"><span class="cdiff-mark">+</span>B</td></tr>
+<tr><td class="cdiff-number cdiff-empty"></td><td class="cdiff-code cdiff-empty"></td><td class="cdiff-number cdiff-insert">3</td><td class="cdiff-code cdiff-insert"><span class="cdiff-mark">+</span>x</td></tr>
+</tbody>
+</table>
+`, builder.String())
+}
+
+func TestHTMLStandalone(t *testing.T) {
+	diff := Diff(src1, src2, WordByWord)
+	var builder strings.Builder
+	assert.Nil(t, diff.HTML(&builder, "old.txt", "new.txt", 1, HTMLOptions{Standalone: true, Title: "<diff>"}))
+	result := builder.String()
+	assert.True(t, strings.HasPrefix(result, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>&lt;diff&gt;</title>\n<style>\n"))
+	assert.True(t, strings.HasSuffix(result, "</table>\n</body>\n</html>\n"))
+	assert.Equal(t, 1, strings.Count(result, "<style>"))
+
+	builder.Reset()
+	assert.Nil(t, diff.HTML(&builder, "old.txt", "new.txt", 1, HTMLOptions{Stylesheet: true}))
+	assert.True(t, strings.HasPrefix(builder.String(), "<style>\n"+HTMLStylesheet+"</style>\n<table"))
+}
@@ -0,0 +1,165 @@
+package cdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// MarshalText encodes Ope as "insert", "delete" or "keep"
+func (o Ope) MarshalText() ([]byte, error) {
+	switch o {
+	case Insert:
+		return []byte("insert"), nil
+	case Delete:
+		return []byte("delete"), nil
+	case Keep:
+		return []byte("keep"), nil
+	}
+	return nil, fmt.Errorf("unknown value of Ope: %d", int(o))
+}
+
+// UnmarshalText decodes "insert", "delete" or "keep"
+func (o *Ope) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "insert":
+		*o = Insert
+	case "delete":
+		*o = Delete
+	case "keep":
+		*o = Keep
+	default:
+		return fmt.Errorf("unknown ope: %q", string(text))
+	}
+	return nil
+}
+
+// MarshalText encodes Move as "", "from" or "to"
+func (m Move) MarshalText() ([]byte, error) {
+	switch m {
+	case NotMoved:
+		return []byte(""), nil
+	case MovedFrom:
+		return []byte("from"), nil
+	case MovedTo:
+		return []byte("to"), nil
+	}
+	return nil, fmt.Errorf("unknown value of Move: %d", int(m))
+}
+
+// UnmarshalText decodes "", "from" or "to"
+func (m *Move) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "":
+		*m = NotMoved
+	case "from":
+		*m = MovedFrom
+	case "to":
+		*m = MovedTo
+	default:
+		return fmt.Errorf("unknown move: %q", string(text))
+	}
+	return nil
+}
+
+// jsonHunk is a hunk in JSON. Lines of the hunk are lines[start:end].
+type jsonHunk struct {
+	Header string `json:"header"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+}
+
+// jsonResult is JSON representation of Result and FileResult
+type jsonResult struct {
+	OldName string     `json:"oldName,omitempty"`
+	NewName string     `json:"newName,omitempty"`
+	Headers []string   `json:"headers,omitempty"`
+	Lines   []Line     `json:"lines"`
+	Hunks   []jsonHunk `json:"hunks"`
+}
+
+func newJSONResult(r Result, l int) jsonResult {
+	result := jsonResult{
+		Lines: r.Lines,
+		Hunks: []jsonHunk{},
+	}
+	if result.Lines == nil {
+		result.Lines = []Line{}
+	}
+	for _, b := range grouping(r.Lines, l) {
+		result.Hunks = append(result.Hunks, jsonHunk{
+			Header: b.section(r.Lines),
+			Start:  b.start,
+			End:    b.end + 1,
+		})
+	}
+	return result
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// MarshalJSON encodes lines and hunks grouped with ContextLines kept lines.
+func (r Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newJSONResult(r, ContextLines))
+}
+
+// UnmarshalJSON decodes lines. Hunks are ignored because they are calculated from lines.
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var result jsonResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	r.Lines = result.Lines
+	return nil
+}
+
+// JSON writes indented JSON of the result. Hunks are grouped with l kept lines.
+func (r Result) JSON(w io.Writer, l int) error {
+	return writeJSON(w, newJSONResult(r, l))
+}
+
+func newFileJSONResult(f FileResult, l int) jsonResult {
+	result := newJSONResult(f.Result, l)
+	result.OldName = f.OldName
+	result.NewName = f.NewName
+	result.Headers = f.Headers
+	return result
+}
+
+// MarshalJSON encodes file names, headers, lines and hunks grouped with ContextLines kept lines.
+func (f FileResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newFileJSONResult(f, ContextLines))
+}
+
+// UnmarshalJSON decodes file names, headers and lines
+func (f *FileResult) UnmarshalJSON(data []byte) error {
+	var result jsonResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	f.OldName = result.OldName
+	f.NewName = result.NewName
+	f.Headers = result.Headers
+	f.Lines = result.Lines
+	return nil
+}
+
+// JSON writes indented JSON of the file. Hunks are grouped with l kept lines.
+func (f FileResult) JSON(w io.Writer, l int) error {
+	return writeJSON(w, newFileJSONResult(f, l))
+}
+
+// JSON writes indented JSON of all files. Hunks are grouped with l kept lines.
+func (m MultiResult) JSON(w io.Writer, l int) error {
+	files := make([]jsonResult, len(m.Files))
+	for i, f := range m.Files {
+		files[i] = newFileJSONResult(f, l)
+	}
+	return writeJSON(w, struct {
+		Files []jsonResult `json:"files"`
+	}{Files: files})
+}
@@ -0,0 +1,93 @@
+package cdiff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpeJSON(t *testing.T) {
+	data, err := json.Marshal([]Ope{Insert, Delete, Keep})
+	assert.Nil(t, err)
+	assert.Equal(t, `["insert","delete","keep"]`, string(data))
+
+	var opes []Ope
+	assert.Nil(t, json.Unmarshal(data, &opes))
+	assert.Equal(t, []Ope{Insert, Delete, Keep}, opes)
+	assert.EqualError(t, json.Unmarshal([]byte(`["@Insert"]`), &opes), `unknown ope: "@Insert"`)
+}
+
+func TestResultJSON(t *testing.T) {
+	diff := Diff("a\nb\n", "a\nc\n", LineByLine)
+	var builder strings.Builder
+	assert.Nil(t, diff.JSON(&builder, 0))
+	assert.Equal(t, `{
+  "lines": [
+    {
+      "ope": "keep",
+      "newLineNumber": 1,
+      "oldLineNumber": 1,
+      "fragments": [
+        {
+          "text": "a",
+          "changed": false
+        }
+      ]
+    },
+    {
+      "ope": "delete",
+      "newLineNumber": -1,
+      "oldLineNumber": 2,
+      "fragments": [
+        {
+          "text": "b",
+          "changed": false
+        }
+      ]
+    },
+    {
+      "ope": "insert",
+      "newLineNumber": 2,
+      "oldLineNumber": -1,
+      "fragments": [
+        {
+          "text": "c",
+          "changed": false
+        }
+      ]
+    }
+  ],
+  "hunks": [
+    {
+      "header": "@@ -2 +2 @@",
+      "start": 1,
+      "end": 3
+    }
+  ]
+}
+`, builder.String())
+}
+
+func TestResultJSONRoundTrip(t *testing.T) {
+	for _, diffType := range []DiffType{LineByLine, WordByWord} {
+		diff := Diff(src1, src2, diffType)
+		data, err := json.Marshal(diff)
+		assert.Nil(t, err)
+		var decoded Result
+		assert.Nil(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, diff, decoded)
+		assert.Equal(t, diff.UnifiedWithTag("old", "new", 1, GooKitColorTag), decoded.UnifiedWithTag("old", "new", 1, GooKitColorTag))
+	}
+}
+
+func TestMultiResultJSONRoundTrip(t *testing.T) {
+	multi, err := ParseUnified(gitDiff, WordByWord)
+	assert.Nil(t, err)
+	data, err := json.Marshal(multi)
+	assert.Nil(t, err)
+	var decoded MultiResult
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, multi.UnifiedWithTag(3, PlainTag), decoded.UnifiedWithTag(3, PlainTag))
+}
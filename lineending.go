@@ -0,0 +1,119 @@
+package cdiff
+
+// LineEnding is a style of line terminators of a text
+type LineEnding int
+
+const (
+	// NoLineEnding is a text that doesn't have line terminators
+	NoLineEnding LineEnding = iota
+	// LF is a text that has only "\n" like Unix
+	LF
+	// CRLF is a text that has only "\r\n" like Windows
+	CRLF
+	// CR is a text that has only "\r" like classic Mac OS
+	CR
+	// MixedLineEnding is a text that has different line terminators
+	MixedLineEnding
+)
+
+func (e LineEnding) String() string {
+	switch e {
+	case LF:
+		return "LF"
+	case CRLF:
+		return "CRLF"
+	case CR:
+		return "CR"
+	case MixedLineEnding:
+		return "mixed"
+	}
+	return "none"
+}
+
+func lineEndingOf(terminator string) LineEnding {
+	switch terminator {
+	case "\n":
+		return LF
+	case "\r\n":
+		return CRLF
+	case "\r":
+		return CR
+	}
+	return NoLineEnding
+}
+
+// merge returns the line ending of a text that has both styles
+func (e LineEnding) merge(other LineEnding) LineEnding {
+	switch {
+	case e == NoLineEnding || e == other:
+		return other
+	case other == NoLineEnding:
+		return e
+	}
+	return MixedLineEnding
+}
+
+// DetectLineEnding returns the line ending style of text
+func DetectLineEnding(text string) LineEnding {
+	result := NoLineEnding
+	for _, line := range splitLinesWithTerminator(text) {
+		_, terminator := splitTerminator(line)
+		result = result.merge(lineEndingOf(terminator))
+	}
+	return result
+}
+
+func (r Result) lineEnding(side func(l Line) bool) LineEnding {
+	result := NoLineEnding
+	for _, l := range r.Lines {
+		if side(l) {
+			result = result.merge(lineEndingOf(l.terminator()))
+		}
+	}
+	return result
+}
+
+// OldLineEnding returns the line ending style of the old text.
+// Kept lines have terminators of the old text, so it can be different from DetectLineEnding() of the new text if Options.IgnoreLineEnding is true.
+func (r Result) OldLineEnding() LineEnding {
+	return r.lineEnding(Line.inOld)
+}
+
+// NewLineEnding returns the line ending style of the new text
+func (r Result) NewLineEnding() LineEnding {
+	return r.lineEnding(Line.inNew)
+}
+
+// markTerminatorChanges sets Line.TerminatorChanged of deleted and inserted lines that differ only in terminators
+func markTerminatorChanges(lines []Line) {
+	for start := 0; start < len(lines); {
+		if lines[start].Ope == Keep {
+			start++
+			continue
+		}
+		end := start
+		var deleted, inserted []int
+		for ; end < len(lines) && lines[end].Ope != Keep; end++ {
+			if lines[end].Ope == Delete {
+				deleted = append(deleted, end)
+			} else {
+				inserted = append(inserted, end)
+			}
+		}
+		next := 0
+		for _, i := range deleted {
+			for j := next; j < len(inserted); j++ {
+				if lines[i].String() != lines[inserted[j]].String() {
+					continue
+				}
+				if lines[i].terminator() != lines[inserted[j]].terminator() {
+					lines[i].TerminatorChanged = true
+					lines[inserted[j]].TerminatorChanged = true
+				}
+				next = j + 1
+				break
+			}
+		}
+		start = end
+	}
+}
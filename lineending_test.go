@@ -0,0 +1,89 @@
+package cdiff
+
+import (
+	"testing"
+
+	"github.com/gookit/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLineEnding(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want LineEnding
+	}{
+		{name: "empty", text: "", want: NoLineEnding},
+		{name: "one line without newline", text: "a", want: NoLineEnding},
+		{name: "LF", text: "a\nb\n", want: LF},
+		{name: "LF without newline at end", text: "a\nb", want: LF},
+		{name: "CRLF", text: "a\r\nb\r\n", want: CRLF},
+		{name: "CR", text: "a\rb\r", want: CR},
+		{name: "mixed", text: "a\r\nb\n", want: MixedLineEnding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectLineEnding(tt.text))
+		})
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		options Options
+		want    string
+		unified string
+	}{
+		{
+			name:    "CRLF to LF",
+			oldText: "a\r\nb\r\nc\r\n",
+			newText: "a\r\nb\nc\r\n",
+			options: Options{Type: WordByWord},
+			want:    "  a\n- b\n+ b\n  c\n",
+			unified: "@@ -1,3 +1,3 @@\n a\n-b^M\n+b\n c\n",
+		},
+		{
+			name:    "CR",
+			oldText: "a\rb\rc\r",
+			newText: "a\rB\rc\r",
+			options: Options{Type: WordByWord},
+			want:    "  a\n- [b]\n+ [B]\n  c\n",
+			unified: "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:    "mixed",
+			oldText: "a\nb\r\nc\r",
+			newText: "a\nb\r\nd\r",
+			options: Options{Type: LineByLine},
+			want:    "  a\n  b\n- c\n+ d\n",
+			unified: "@@ -2,2 +2,2 @@\n b\n-c\n+d\n",
+		},
+		{
+			name:    "ignore line ending",
+			oldText: "a\r\nb\r\n",
+			newText: "a\nc\r",
+			options: Options{Type: LineByLine, IgnoreLineEnding: true},
+			want:    "  a\n- b\n+ c\n",
+			unified: "@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffWithOptions(tt.oldText, tt.newText, tt.options)
+			assert.Equal(t, tt.want, dumpForTest(got))
+			assert.Equal(t, tt.oldText, got.Old())
+			assert.Equal(t, DetectLineEnding(tt.oldText), got.OldLineEnding())
+			assert.Equal(t, "--- old\n+++ new\n"+tt.unified, color.ClearTag(got.UnifiedWithTag("old", "new", 1, GooKitColorTag)))
+			if !tt.options.IgnoreLineEnding {
+				assert.Equal(t, tt.newText, got.New())
+				assert.Equal(t, DetectLineEnding(tt.newText), got.NewLineEnding())
+				applied, err := got.ApplyTo(tt.oldText)
+				assert.Nil(t, err)
+				assert.Equal(t, tt.newText, applied)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package cdiff
+
+import (
+	"strings"
+)
+
+// Conflict represents a region that both sides changed differently
+type Conflict struct {
+	// BaseLineNumber, OursLineNumber and TheirsLineNumber are 1 origin start lines of the region in each text
+	BaseLineNumber   int
+	OursLineNumber   int
+	TheirsLineNumber int
+	Base             []string
+	Ours             []string
+	Theirs           []string
+}
+
+// MergeChunk is a part of merge result. It has resolved lines or a conflict.
+type MergeChunk struct {
+	Lines    []string
+	Conflict *Conflict
+}
+
+// MergeResult contains result of Merge3()
+type MergeResult struct {
+	Chunks []MergeChunk
+	eol    bool
+}
+
+// Conflicts returns all conflicts in the result
+func (m MergeResult) Conflicts() []Conflict {
+	var result []Conflict
+	for _, c := range m.Chunks {
+		if c.Conflict != nil {
+			result = append(result, *c.Conflict)
+		}
+	}
+	return result
+}
+
+// HasConflicts returns true if the result has any conflicts
+func (m MergeResult) HasConflicts() bool {
+	for _, c := range m.Chunks {
+		if c.Conflict != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Format returns merged text. Conflicts are written with conflict markers labeled by given labels.
+//
+// If diff3 is true, base lines are written in conflicts too like "diff3" conflict style of git.
+func (m MergeResult) Format(oursLabel, baseLabel, theirsLabel string, diff3 bool) string {
+	var lines []string
+	for _, c := range m.Chunks {
+		if c.Conflict == nil {
+			lines = append(lines, c.Lines...)
+			continue
+		}
+		lines = append(lines, "<<<<<<< "+oursLabel)
+		lines = append(lines, c.Conflict.Ours...)
+		if diff3 {
+			lines = append(lines, "||||||| "+baseLabel)
+			lines = append(lines, c.Conflict.Base...)
+		}
+		lines = append(lines, "=======")
+		lines = append(lines, c.Conflict.Theirs...)
+		lines = append(lines, ">>>>>>> "+theirsLabel)
+	}
+	result := strings.Join(lines, "\n")
+	if m.eol && len(lines) > 0 {
+		result += "\n"
+	}
+	return result
+}
+
+func (m MergeResult) String() string {
+	return m.Format("ours", "base", "theirs", false)
+}
+
+func (m *MergeResult) add(lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	if len(m.Chunks) > 0 && m.Chunks[len(m.Chunks)-1].Conflict == nil {
+		last := &m.Chunks[len(m.Chunks)-1]
+		last.Lines = append(last.Lines, lines...)
+		return
+	}
+	m.Chunks = append(m.Chunks, MergeChunk{Lines: append([]string(nil), lines...)})
+}
+
+// change replaces base lines [start, end) with lines
+type change struct {
+	start int
+	end   int
+	lines []string
+}
+
+// textLines splits text into lines like calcBlockDiff(). Lines don't have "\n", but keep "\r" of "\r\n" and "\r".
+func textLines(text string) []string {
+	var lines []string
+	for _, line := range splitLinesWithTerminator(text) {
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	return lines
+}
+
+// splitText returns lines of text and true if the text ends with newline
+func splitText(text string) (lines []string, eol bool) {
+	lines = textLines(text)
+	eol = text == "" || strings.HasSuffix(text, "\n")
+	return lines, eol
+}
+
+func blocksToChanges(blocks []blockDiff) []change {
+	var result []change
+	pos := 0
+	inChange := false
+	for _, b := range blocks {
+		lines := textLines(b.Text)
+		if b.Ope == Keep {
+			pos += len(lines)
+			inChange = false
+			continue
+		}
+		if !inChange {
+			result = append(result, change{start: pos, end: pos})
+			inChange = true
+		}
+		c := &result[len(result)-1]
+		if b.Ope == Delete {
+			c.end += len(lines)
+			pos += len(lines)
+		} else {
+			c.lines = append(c.lines, lines...)
+		}
+	}
+	return result
+}
+
+// applyChanges returns base lines [start, end) with changes applied
+func applyChanges(base []string, start, end int, changes []change) []string {
+	var result []string
+	pos := start
+	for _, c := range changes {
+		result = append(result, base[pos:c.start]...)
+		result = append(result, c.lines...)
+		pos = c.end
+	}
+	return append(result, base[pos:end]...)
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge3 merges changes from base to ours and from base to theirs.
+//
+// Changes that don't overlap are merged automatically. Overlapping different changes become conflicts.
+func Merge3(base, ours, theirs string) MergeResult {
+	baseLines, baseEol := splitText(base)
+	_, oursEol := splitText(ours)
+	_, theirsEol := splitText(theirs)
+	result := MergeResult{eol: oursEol}
+	if oursEol == baseEol {
+		result.eol = theirsEol
+	}
+	a := blocksToChanges(calcBlockDiff(base, ours, Options{}))
+	b := blocksToChanges(calcBlockDiff(base, theirs, Options{}))
+	pos := 0
+	// differences of line numbers between base and each side
+	oursDelta, theirsDelta := 0, 0
+	for len(a) > 0 || len(b) > 0 {
+		regionStart := -1
+		if len(a) > 0 {
+			regionStart = a[0].start
+		}
+		if len(b) > 0 && (regionStart == -1 || b[0].start < regionStart) {
+			regionStart = b[0].start
+		}
+		regionEnd := regionStart
+		var oursChanges, theirsChanges []change
+		// collect changes that overlap or touch the region from both sides
+		for {
+			if len(a) > 0 && a[0].start <= regionEnd {
+				if a[0].end > regionEnd {
+					regionEnd = a[0].end
+				}
+				oursChanges = append(oursChanges, a[0])
+				a = a[1:]
+			} else if len(b) > 0 && b[0].start <= regionEnd {
+				if b[0].end > regionEnd {
+					regionEnd = b[0].end
+				}
+				theirsChanges = append(theirsChanges, b[0])
+				b = b[1:]
+			} else {
+				break
+			}
+		}
+		result.add(baseLines[pos:regionStart])
+		oursRegion := applyChanges(baseLines, regionStart, regionEnd, oursChanges)
+		theirsRegion := applyChanges(baseLines, regionStart, regionEnd, theirsChanges)
+		switch {
+		case len(theirsChanges) == 0:
+			result.add(oursRegion)
+		case len(oursChanges) == 0 || equalLines(oursRegion, theirsRegion):
+			result.add(theirsRegion)
+		default:
+			result.Chunks = append(result.Chunks, MergeChunk{
+				Conflict: &Conflict{
+					BaseLineNumber:   regionStart + 1,
+					OursLineNumber:   regionStart + oursDelta + 1,
+					TheirsLineNumber: regionStart + theirsDelta + 1,
+					Base:             baseLines[regionStart:regionEnd],
+					Ours:             oursRegion,
+					Theirs:           theirsRegion,
+				},
+			})
+		}
+		regionLength := regionEnd - regionStart
+		oursDelta += len(oursRegion) - regionLength
+		theirsDelta += len(theirsRegion) - regionLength
+		pos = regionEnd
+	}
+	result.add(baseLines[pos:])
+	return result
+}
@@ -0,0 +1,49 @@
+package cdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge3(t *testing.T) {
+	base := "a\nb\nc\nd\ne\nf\ng\n"
+	t.Run("no conflict", func(t *testing.T) {
+		result := Merge3(base, "A\nb\nc\nd\ne\nf\ng\n", "a\nb\nc\nd\ne\nf\nG\nh\n")
+		assert.False(t, result.HasConflicts())
+		assert.Equal(t, "A\nb\nc\nd\ne\nf\nG\nh\n", result.String())
+	})
+	t.Run("same change", func(t *testing.T) {
+		result := Merge3(base, "a\nb\nC\nd\ne\nf\ng\n", "a\nb\nC\nd\ne\nf\ng\n")
+		assert.False(t, result.HasConflicts())
+		assert.Equal(t, "a\nb\nC\nd\ne\nf\ng\n", result.String())
+	})
+	t.Run("conflict", func(t *testing.T) {
+		result := Merge3(base, "x\na\nb\nours\nd\ne\nf\ng\n", "a\nb\ntheirs\ntheirs2\nd\ne\nf\ng\n")
+		assert.True(t, result.HasConflicts())
+		assert.Equal(t, []Conflict{
+			{
+				BaseLineNumber:   3,
+				OursLineNumber:   4,
+				TheirsLineNumber: 3,
+				Base:             []string{"c"},
+				Ours:             []string{"ours"},
+				Theirs:           []string{"theirs", "theirs2"},
+			},
+		}, result.Conflicts())
+		assert.Equal(t, "x\na\nb\n<<<<<<< ours\nours\n=======\ntheirs\ntheirs2\n>>>>>>> theirs\nd\ne\nf\ng\n", result.String())
+		assert.Equal(t, "x\na\nb\n<<<<<<< mine\nours\n||||||| orig\nc\n=======\ntheirs\ntheirs2\n>>>>>>> yours\nd\ne\nf\ng\n", result.Format("mine", "orig", "yours", true))
+	})
+	t.Run("deleted and changed", func(t *testing.T) {
+		result := Merge3(base, "a\nb\nd\ne\nf\ng\n", "a\nb\nc2\nd\ne\nf\ng\n")
+		assert.Equal(t, []Conflict{
+			{
+				BaseLineNumber:   3,
+				OursLineNumber:   3,
+				TheirsLineNumber: 3,
+				Base:             []string{"c"},
+				Theirs:           []string{"c2"},
+			},
+		}, result.Conflicts())
+	})
+}
@@ -0,0 +1,82 @@
+package cdiff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minMovedAlnums is the minimum count of alphanumeric characters of moved blocks like git
+const minMovedAlnums = 20
+
+// movedKey returns text to find moved lines. Differences of white space are ignored.
+func movedKey(l Line) string {
+	return strings.Join(strings.Fields(l.String()), " ")
+}
+
+func countAlnums(text string) int {
+	count := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			count++
+		}
+	}
+	return count
+}
+
+// detectMoves sets Move of blocks of lines that are deleted and inserted verbatim in another change.
+// Blocks that have less than minMovedAlnums alphanumeric characters are not marked.
+func detectMoves(lines []Line) {
+	// regions[i] is the index of the change region of lines[i]
+	regions := make([]int, len(lines))
+	region := 0
+	var deleted []int
+	insertedByKey := make(map[string][]int)
+	for i, l := range lines {
+		switch l.Ope {
+		case Keep:
+			region++
+		case Delete:
+			deleted = append(deleted, i)
+		case Insert:
+			key := movedKey(l)
+			insertedByKey[key] = append(insertedByKey[key], i)
+		}
+		regions[i] = region
+	}
+	// matches returns true if the line can be a part of a moved block
+	matches := func(from, to int) bool {
+		return from < len(lines) && to < len(lines) &&
+			lines[from].Ope == Delete && lines[to].Ope == Insert &&
+			lines[from].Move == NotMoved && lines[to].Move == NotMoved &&
+			regions[from] != regions[to] && movedKey(lines[from]) == movedKey(lines[to])
+	}
+	for _, from := range deleted {
+		if lines[from].Move != NotMoved {
+			continue
+		}
+		bestTo, bestLength := -1, 0
+		for _, to := range insertedByKey[movedKey(lines[from])] {
+			length := 0
+			for matches(from+length, to+length) {
+				length++
+			}
+			if length > bestLength {
+				bestTo, bestLength = to, length
+			}
+		}
+		if bestLength == 0 {
+			continue
+		}
+		alnums := 0
+		for i := 0; i < bestLength; i++ {
+			alnums += countAlnums(lines[from+i].String())
+		}
+		if alnums < minMovedAlnums {
+			continue
+		}
+		for i := 0; i < bestLength; i++ {
+			lines[from+i].Move = MovedFrom
+			lines[bestTo+i].Move = MovedTo
+		}
+	}
+}
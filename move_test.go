@@ -0,0 +1,88 @@
+package cdiff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const movedOld = `func first() {
+	return computeSomething(1)
+}
+
+func second() {
+	return 2
+}
+`
+
+const movedNew = `func second() {
+	return 2
+}
+
+func first() {
+    return computeSomething(1)
+}
+`
+
+func moves(r Result) string {
+	var builder strings.Builder
+	for _, l := range r.Lines {
+		switch {
+		case l.Move == MovedFrom:
+			builder.WriteString("<")
+		case l.Move == MovedTo:
+			builder.WriteString(">")
+		case l.Ope == Insert:
+			builder.WriteString("+")
+		case l.Ope == Delete:
+			builder.WriteString("-")
+		default:
+			builder.WriteString(" ")
+		}
+	}
+	return builder.String()
+}
+
+func TestDetectMoves(t *testing.T) {
+	result := DiffWithOptions(movedOld, movedNew, Options{Type: LineByLine | Patience, DetectMoves: true})
+	assert.Equal(t, "<<<-   +>>>", moves(result))
+
+	result = DiffWithOptions(movedOld, movedNew, Options{Type: LineByLine | Patience})
+	assert.Equal(t, "----   ++++", moves(result))
+}
+
+func TestDetectMovesStream(t *testing.T) {
+	options := Options{Type: LineByLine | Patience, DetectMoves: true}
+	assert.Equal(t, moves(DiffWithOptions(movedOld, movedNew, options)), moves(collectLines(t, movedOld, movedNew, options, streamWindow)))
+}
+
+func TestDetectMovesShortBlock(t *testing.T) {
+	result := DiffWithOptions("a\nb\nc\nd\n", "b\nc\nd\na\n", Options{Type: LineByLine, DetectMoves: true})
+	assert.Equal(t, "-   +", moves(result))
+}
+
+func TestRenderMoves(t *testing.T) {
+	result := DiffWithOptions(movedOld, movedNew, Options{Type: WordByWord | Patience, DetectMoves: true})
+	unified := result.UnifiedWithTag("old", "new", 3, GooKitColorTag)
+	assert.Contains(t, unified, "<fg=magenta;op=bold>-</><fg=magenta;op=bold>func first() {</>\n")
+	assert.Contains(t, unified, "<fg=cyan;op=bold>+</><fg=cyan;op=bold>func first() {</>\n")
+	// themes that don't have moved tags use normal tags
+	assert.Contains(t, result.UnifiedWithTag("old", "new", 3, map[Tag]string{
+		OpenDeletedNotModified:  "<red>",
+		CloseDeletedNotModified: "</>",
+	}), "<red>-</>func first() {")
+
+	var builder strings.Builder
+	assert.Nil(t, result.HTML(&builder, "old", "new", 3, HTMLOptions{}))
+	assert.Contains(t, builder.String(), `<tr class="cdiff-delete cdiff-moved-from">`)
+	assert.Contains(t, builder.String(), `<tr class="cdiff-insert cdiff-moved-to">`)
+
+	data, err := json.Marshal(result)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"move":"from"`)
+	var decoded Result
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, result, decoded)
+}
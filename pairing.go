@@ -0,0 +1,172 @@
+package cdiff
+
+import (
+	"unicode/utf8"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const (
+	// defaultSimilarityThreshold is the default value of Options.SimilarityThreshold
+	defaultSimilarityThreshold = 0.5
+	// maxPairingCells is the limit of the size of the table to find the best pairs.
+	// Larger change regions pair lines at the same positions only.
+	maxPairingCells = 10000
+)
+
+// levenshtein returns the Levenshtein distance of diffs in runes.
+// DiffLevenshtein() of go-diff counts bytes, so it can't be compared with lengths of multibyte texts.
+func levenshtein(diffs []diffmatchpatch.Diff) int {
+	distance, inserted, deleted := 0, 0, 0
+	for _, diff := range diffs {
+		switch diff.Type {
+		case diffmatchpatch.DiffInsert:
+			inserted += utf8.RuneCountInString(diff.Text)
+		case diffmatchpatch.DiffDelete:
+			deleted += utf8.RuneCountInString(diff.Text)
+		default:
+			distance += maxInt(inserted, deleted)
+			inserted, deleted = 0, 0
+		}
+	}
+	return distance + maxInt(inserted, deleted)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// similarity returns 1 - (Levenshtein distance / length of longer text)
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	length := maxInt(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	dmp := diffmatchpatch.New()
+	return 1 - float64(levenshtein(dmp.DiffMain(a, b, false)))/float64(length)
+}
+
+func (o Options) similarityThreshold() float64 {
+	if o.SimilarityThreshold > 0 {
+		return o.SimilarityThreshold
+	}
+	return defaultSimilarityThreshold
+}
+
+// pairLinesBySimilarity returns the index of the paired new line for each old line, or -1.
+// Pairs keep the order of lines and have the largest total similarity.
+func (o Options) pairLinesBySimilarity(oldLines, newLines []string) []int {
+	threshold := o.similarityThreshold()
+	pairs := make([]int, len(oldLines))
+	for i := range pairs {
+		pairs[i] = -1
+	}
+	if len(oldLines)*len(newLines) > maxPairingCells {
+		for i := 0; i < len(oldLines) && i < len(newLines); i++ {
+			if similarity(o.normalize(oldLines[i]), o.normalize(newLines[i])) >= threshold {
+				pairs[i] = i
+			}
+		}
+		return pairs
+	}
+	// scores[i][j] is the best total similarity of oldLines[:i] and newLines[:j]
+	scores := make([][]float64, len(oldLines)+1)
+	for i := range scores {
+		scores[i] = make([]float64, len(newLines)+1)
+	}
+	similarities := make([][]float64, len(oldLines))
+	for i := range oldLines {
+		similarities[i] = make([]float64, len(newLines))
+		for j := range newLines {
+			similarities[i][j] = similarity(o.normalize(oldLines[i]), o.normalize(newLines[j]))
+			best := scores[i][j+1]
+			if scores[i+1][j] > best {
+				best = scores[i+1][j]
+			}
+			if similarities[i][j] >= threshold && scores[i][j]+similarities[i][j] > best {
+				best = scores[i][j] + similarities[i][j]
+			}
+			scores[i+1][j+1] = best
+		}
+	}
+	for i, j := len(oldLines), len(newLines); i > 0 && j > 0; {
+		switch {
+		case scores[i][j] == scores[i-1][j]:
+			i--
+		case scores[i][j] == scores[i][j-1]:
+			j--
+		default:
+			pairs[i-1] = j - 1
+			i--
+			j--
+		}
+	}
+	return pairs
+}
+
+// changedFragments returns fragments of a line that doesn't have a paired line
+func (o Options) changedFragments(text string) []Fragment {
+	fragments := o.appendFragment(nil, text, true)
+	if len(fragments) == 0 {
+		fragments = []Fragment{{Text: text, Changed: true}}
+	}
+	return fragments
+}
+
+// pairChangedLines converts a Delete block and an Insert block into lines.
+// Similar lines are paired and refined word by word. Other lines are marked as changed entirely.
+func pairChangedLines(deleted, inserted blockDiff, options Options) []Line {
+	oldLines, oldTerminators := deleted.splitLines()
+	newLines, newTerminators := inserted.splitLines()
+	oldFragments := make([][]Fragment, len(oldLines))
+	newFragments := make([][]Fragment, len(newLines))
+	for i, j := range options.pairLinesBySimilarity(oldLines, newLines) {
+		if j == -1 {
+			continue
+		}
+		for _, diff := range options.fragmentDiffs(oldLines[i], newLines[j]) {
+			switch diff.Type {
+			case diffmatchpatch.DiffDelete:
+				oldFragments[i] = options.appendFragment(oldFragments[i], diff.Text, true)
+			case diffmatchpatch.DiffInsert:
+				newFragments[j] = options.appendFragment(newFragments[j], diff.Text, true)
+			default:
+				oldFragments[i] = options.appendFragment(oldFragments[i], diff.Text, false)
+				newFragments[j] = options.appendFragment(newFragments[j], diff.Text, false)
+			}
+		}
+	}
+	result := make([]Line, 0, len(oldLines)+len(newLines))
+	for i, text := range oldLines {
+		fragments := oldFragments[i]
+		if fragments == nil {
+			fragments = options.changedFragments(text)
+		}
+		l := Line{
+			Ope:           Delete,
+			NewLineNumber: -1,
+			OldLineNumber: deleted.OldLineNumber + i,
+			Fragments:     fragments,
+		}
+		l.setTerminator(oldTerminators[i])
+		result = append(result, l)
+	}
+	for j, text := range newLines {
+		fragments := newFragments[j]
+		if fragments == nil {
+			fragments = options.changedFragments(text)
+		}
+		l := Line{
+			Ope:           Insert,
+			NewLineNumber: inserted.NewLineNumber + j,
+			OldLineNumber: -1,
+			Fragments:     fragments,
+		}
+		l.setTerminator(newTerminators[j])
+		result = append(result, l)
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package cdiff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, similarity("", ""))
+	assert.Equal(t, 1.0, similarity("abc", "abc"))
+	assert.Equal(t, 0.0, similarity("abc", ""))
+	assert.InDelta(t, 0.75, similarity("abcd", "abce"), 0.001)
+	// distances are counted in runes
+	assert.InDelta(t, 5.0/7.0, similarity("今日は晴れです", "今日は雨です"), 0.001)
+}
+
+func TestPairLinesBySimilarity(t *testing.T) {
+	options := Options{}
+	assert.Equal(t, []int{-1, 0, 1}, options.pairLinesBySimilarity(
+		[]string{"unrelated line", "func foo(a int) {", "return a"},
+		[]string{"func foo(a, b int) {", "return a + b"}))
+	assert.Equal(t, []int{-1, -1}, options.pairLinesBySimilarity(
+		[]string{"abc", "def"},
+		[]string{"xyz"}))
+	// crossed lines are not paired
+	assert.Equal(t, []int{-1, 0}, options.pairLinesBySimilarity(
+		[]string{"first line", "second line"},
+		[]string{"second line!", "first line!"}))
+}
+
+func TestWordDiffPairing(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		options Options
+		want    string
+	}{
+		{
+			name:    "unrelated lines are changed entirely",
+			oldText: "keep\nlorem ipsum\ndolor sit amet\nfunc foo(a int) {\n",
+			newText: "keep\nfunc foo(a, b int) {\n",
+			options: Options{Type: WordByWord},
+			want:    "  keep\n- [lorem ipsum]\n- [dolor sit amet]\n- func foo(a int) {\n+ func foo(a[, b] int) {\n",
+		},
+		{
+			name:    "short japanese line",
+			oldText: "今日は晴れです\n",
+			newText: "今日は雨です\n",
+			options: Options{Type: WordByWord},
+			want:    "- 今日は[晴れ]です\n+ 今日は[雨]です\n",
+		},
+		{
+			name:    "emoji",
+			oldText: "👍🏽 ok\n",
+			newText: "👍🏿 ok\n",
+			options: Options{Type: WordByWord},
+			want:    "- [👍🏽] ok\n+ [👍🏿] ok\n",
+		},
+		{
+			name:    "high threshold",
+			oldText: "abcd\n",
+			newText: "abce\n",
+			options: Options{Type: WordByWord, SimilarityThreshold: 0.9},
+			want:    "- [abcd]\n+ [abce]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DiffWithOptions(tt.oldText, tt.newText, tt.options)
+			assert.Equal(t, tt.want, dumpForTest(result))
+			assert.Equal(t, tt.oldText, result.Old())
+			assert.Equal(t, tt.newText, result.New())
+		})
+	}
+}
+
+func TestWordDiffPairingLargeRegion(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 200; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("line %d: old\n", i))
+		newLines = append(newLines, fmt.Sprintf("line %d: new\n", i))
+	}
+	result := Diff(strings.Join(oldLines, ""), strings.Join(newLines, ""), WordByWord)
+	assert.Equal(t, "- line 0: [old]", strings.SplitN(dumpForTest(result), "\n", 2)[0])
+}
@@ -0,0 +1,194 @@
+package cdiff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileResult contains diff result of one file in a multi-file diff
+type FileResult struct {
+	OldName string
+	NewName string
+	// Headers contains extended header lines before "---" like "diff --git a/x b/x" or "index 1234567..89abcde"
+	Headers []string
+	Result
+}
+
+// MultiResult contains diff results of multiple files
+type MultiResult struct {
+	Files []FileResult `json:"files"`
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+func parseHunkHeader(line string) (oldStart, oldCount, newStart, newCount int, ok bool) {
+	match := hunkHeaderPattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, 0, 0, 0, false
+	}
+	number := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	return number(match[1]), number(match[2]), number(match[3]), number(match[4]), true
+}
+
+// parseFileName removes tab separated timestamp from "---" and "+++" lines
+func parseFileName(line string) string {
+	name := line[4:]
+	if i := strings.IndexByte(name, '\t'); i != -1 {
+		name = name[:i]
+	}
+	return name
+}
+
+// hunkParser accumulates lines of a hunk into blocks
+type hunkParser struct {
+	blocks        []blockDiff
+	oldLineNumber int
+	newLineNumber int
+	oldRemaining  int
+	newRemaining  int
+}
+
+func (h *hunkParser) add(ope Ope, text string) {
+	if len(h.blocks) == 0 || h.blocks[len(h.blocks)-1].Ope != ope {
+		b := blockDiff{
+			Ope:           ope,
+			OldLineNumber: -1,
+			NewLineNumber: -1,
+			LFOnly:        true,
+		}
+		if ope != Insert {
+			b.OldLineNumber = h.oldLineNumber
+		}
+		if ope != Delete {
+			b.NewLineNumber = h.newLineNumber
+		}
+		h.blocks = append(h.blocks, b)
+	}
+	h.blocks[len(h.blocks)-1].Text += text + "\n"
+	if ope != Insert {
+		h.oldLineNumber++
+		h.oldRemaining--
+	}
+	if ope != Delete {
+		h.newLineNumber++
+		h.newRemaining--
+	}
+}
+
+// noNewline removes newline of the last line for "\ No newline at end of file" marker
+func (h *hunkParser) noNewline() {
+	if len(h.blocks) > 0 {
+		last := &h.blocks[len(h.blocks)-1]
+		last.Text = strings.TrimSuffix(last.Text, "\n")
+	}
+}
+
+func (h *hunkParser) done() bool {
+	return h.oldRemaining <= 0 && h.newRemaining <= 0
+}
+
+// ParseUnified parses unified diff text like output of "git diff" or "diff -u".
+//
+// diffType works like Diff(). If it is WordByWord, paired deleted/inserted lines in each hunk are refined word by word.
+func ParseUnified(text string, diffType DiffType) (MultiResult, error) {
+	var result MultiResult
+	var file *FileResult
+	var hunk *hunkParser
+	newFile := func() {
+		result.Files = append(result.Files, FileResult{})
+		file = &result.Files[len(result.Files)-1]
+	}
+	closeHunk := func() {
+		if diffType&granularityMask == LineByLine {
+			file.Lines = append(file.Lines, lineDiffBlocks(hunk.blocks)...)
+		} else {
+			file.Lines = append(file.Lines, wordDiffBlocks(hunk.blocks, Options{Type: diffType})...)
+		}
+		hunk = nil
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		if hunk != nil {
+			switch {
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file" marker
+				hunk.noNewline()
+			case line == "" || line[0] == ' ':
+				hunk.add(Keep, strings.TrimPrefix(line, " "))
+			case line[0] == '-':
+				hunk.add(Delete, line[1:])
+			case line[0] == '+':
+				hunk.add(Insert, line[1:])
+			default:
+				return result, fmt.Errorf("unexpected line in hunk at line %d: %q", i+1, line)
+			}
+			if hunk.done() && (i+1 == len(lines) || !strings.HasPrefix(lines[i+1], `\`)) {
+				closeHunk()
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			newFile()
+			file.Headers = append(file.Headers, line)
+		case strings.HasPrefix(line, "--- "):
+			if file == nil || file.OldName != "" || file.NewName != "" || len(file.Lines) > 0 {
+				newFile()
+			}
+			file.OldName = parseFileName(line)
+		case strings.HasPrefix(line, "+++ ") && file != nil:
+			file.NewName = parseFileName(line)
+		case strings.HasPrefix(line, "@@ "):
+			if file == nil {
+				return result, fmt.Errorf("hunk without file header at line %d", i+1)
+			}
+			oldStart, oldCount, newStart, newCount, ok := parseHunkHeader(line)
+			if !ok {
+				return result, fmt.Errorf("invalid hunk header at line %d: %q", i+1, line)
+			}
+			// line number of an empty range points the line before the hunk
+			if oldCount == 0 {
+				oldStart++
+			}
+			if newCount == 0 {
+				newStart++
+			}
+			hunk = &hunkParser{
+				oldLineNumber: oldStart,
+				newLineNumber: newStart,
+				oldRemaining:  oldCount,
+				newRemaining:  newCount,
+			}
+			if hunk.done() {
+				closeHunk()
+			}
+		default:
+			if file == nil || len(file.Lines) > 0 || file.OldName != "" {
+				newFile()
+			}
+			file.Headers = append(file.Headers, line)
+		}
+	}
+	if hunk != nil {
+		return result, fmt.Errorf("unexpected end of hunk at line %d", len(lines))
+	}
+	return result, nil
+}
+
+// UnifiedWithTag returns unified format diff text of all files
+func (m MultiResult) UnifiedWithTag(l int, theme map[Tag]string) string {
+	var builder strings.Builder
+	m.Render(&builder, l, NewTagRenderer(theme))
+	return builder.String()
+}
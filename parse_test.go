@@ -0,0 +1,75 @@
+package cdiff
+
+import (
+	"testing"
+
+	"github.com/gookit/color"
+	"github.com/stretchr/testify/assert"
+)
+
+var gitDiff = `diff --git a/hello.txt b/hello.txt
+index 1234567..89abcde 100644
+--- a/hello.txt
++++ b/hello.txt
+@@ -1,3 +1,3 @@
+     abc
+-    def
++    deg
+     ghi
+@@ -10,2 +10,3 @@ func main() {
+ jkl
++--- not a header
+ mno
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/new.txt	2020-01-02 03:04:05.000000000 +0900
+@@ -0,0 +1 @@
++no newline
+\ No newline at end of file
+`
+
+func TestParseUnified(t *testing.T) {
+	result, err := ParseUnified(gitDiff, WordByWord)
+	assert.Nil(t, err)
+	if !assert.Equal(t, 2, len(result.Files)) {
+		return
+	}
+	first := result.Files[0]
+	assert.Equal(t, "a/hello.txt", first.OldName)
+	assert.Equal(t, "b/hello.txt", first.NewName)
+	assert.Equal(t, []string{"diff --git a/hello.txt b/hello.txt", "index 1234567..89abcde 100644"}, first.Headers)
+	assert.Equal(t, "      abc\n-     de[f]\n+     de[g]\n      ghi\n  jkl\n+ [--- not a header]\n  mno\n", dumpForTest(first.Result))
+	assert.Equal(t, Line{Ope: Insert, OldLineNumber: -1, NewLineNumber: 11, Fragments: []Fragment{{Text: "--- not a header", Changed: true}}}, first.Lines[5])
+	assert.Equal(t, Line{Ope: Keep, OldLineNumber: 11, NewLineNumber: 12, Fragments: []Fragment{{Text: "mno"}}}, first.Lines[6])
+
+	second := result.Files[1]
+	assert.Equal(t, "/dev/null", second.OldName)
+	assert.Equal(t, "b/new.txt", second.NewName)
+	assert.Equal(t, []Line{{Ope: Insert, OldLineNumber: -1, NewLineNumber: 1, Fragments: []Fragment{{Text: "no newline", Changed: true}}, NoNewline: true}}, second.Lines)
+}
+
+func TestParseUnifiedRoundTrip(t *testing.T) {
+	result, err := ParseUnified(expectedResult, WordByWord)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResult, color.ClearTag(result.UnifiedWithTag(1, GooKitColorTag)))
+	assert.Equal(t, Diff(src1, src2, WordByWord).Lines, result.Files[0].Lines)
+}
+
+func TestParseUnifiedError(t *testing.T) {
+	_, err := ParseUnified("--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n", LineByLine)
+	assert.NotNil(t, err)
+	_, err = ParseUnified("--- a\n+++ b\n@@ -1 +1 @@\n?a\n", LineByLine)
+	assert.NotNil(t, err)
+}
+
+func TestParseUnifiedCarriageReturn(t *testing.T) {
+	result, err := ParseUnified("--- a\n+++ b\n@@ -1,2 +1,2 @@\n-a\rb\n+a\rc\n k\n", LineByLine)
+	assert.Nil(t, err)
+	lines := result.Files[0].Lines
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "a\rb", lines[0].String())
+	assert.Equal(t, []int{1, -1, 2}, []int{lines[0].OldLineNumber, lines[1].OldLineNumber, lines[2].OldLineNumber})
+	assert.Equal(t, "", lines[0].Terminator)
+}
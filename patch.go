@@ -0,0 +1,272 @@
+package cdiff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ContextLines is the number of context lines used by Result.ApplyTo() to locate changes
+const ContextLines = 3
+
+func (r Result) text(filter func(l Line) bool, newSide bool) string {
+	var builder strings.Builder
+	for _, l := range r.Lines {
+		if !filter(l) {
+			continue
+		}
+		if newSide {
+			l = l.newSide()
+		}
+		builder.WriteString(l.String())
+		builder.WriteString(l.terminator())
+	}
+	return builder.String()
+}
+
+// Old returns the old text reconstructed from kept and deleted lines
+func (r Result) Old() string {
+	return r.text(Line.inOld, false)
+}
+
+// New returns the new text reconstructed from kept and inserted lines
+func (r Result) New() string {
+	return r.text(Line.inNew, true)
+}
+
+// HunkResult reports how a hunk was applied by Patch()
+type HunkResult struct {
+	// Index is 1 origin index of the hunk
+	Index int
+	// OldLineNumber is the start line of the hunk written in the patch
+	OldLineNumber int
+	// AppliedLineNumber is the start line where the hunk was found in the old text. It is -1 if the hunk is failed.
+	AppliedLineNumber int
+	// Offset is the difference between AppliedLineNumber and OldLineNumber
+	Offset int
+	// Fuzz is the number of context lines ignored at each side of the hunk
+	Fuzz int
+}
+
+// Applied returns true if the hunk was applied
+func (h HunkResult) Applied() bool {
+	return h.AppliedLineNumber > 0
+}
+
+// String returns a report message like GNU patch
+func (h HunkResult) String() string {
+	if !h.Applied() {
+		return "Hunk #" + strconv.Itoa(h.Index) + " FAILED at " + strconv.Itoa(h.OldLineNumber) + "."
+	}
+	message := "Hunk #" + strconv.Itoa(h.Index) + " succeeded at " + strconv.Itoa(h.AppliedLineNumber)
+	if h.Fuzz > 0 {
+		message += " with fuzz " + strconv.Itoa(h.Fuzz)
+	}
+	switch h.Offset {
+	case 0:
+	case 1:
+		message += " (offset " + strconv.Itoa(h.Offset) + " line)"
+	default:
+		message += " (offset " + strconv.Itoa(h.Offset) + " lines)"
+	}
+	return message + "."
+}
+
+// PatchResult contains a patched text and reports of each hunk
+type PatchResult struct {
+	Text  string
+	Hunks []HunkResult
+}
+
+type hunk struct {
+	lines    []Line
+	oldStart int
+}
+
+func (r Result) hunks(context int) []hunk {
+	var result []hunk
+	// delta is the number of inserted lines minus deleted lines before the hunk
+	delta, counted := 0, 0
+	for _, b := range grouping(r.Lines, context) {
+		for ; counted < b.start; counted++ {
+			switch r.Lines[counted].Ope {
+			case Insert:
+				delta++
+			case Delete:
+				delta--
+			}
+		}
+		h := hunk{
+			lines:    r.Lines[b.start : b.end+1],
+			oldStart: 1,
+		}
+		found := false
+		for _, l := range h.lines {
+			if l.OldLineNumber > 0 {
+				h.oldStart = l.OldLineNumber
+				found = true
+				break
+			}
+		}
+		// pure insertion hunk doesn't have old line numbers. the old position is shifted by the changes before the hunk.
+		// lines between hunks of parsed patches are kept lines, so they don't change delta.
+		if !found && h.lines[0].NewLineNumber > 0 {
+			h.oldStart = h.lines[0].NewLineNumber - delta
+		}
+		result = append(result, h)
+	}
+	return result
+}
+
+// sides returns old side and new side lines of the hunk without ignored context lines.
+// Lines have terminators of the patch. kept[i] is the index in oldLines of the kept line newLines[i], or -1.
+func (h hunk) sides(fuzz int) (oldLines, newLines []string, kept []int, lead int) {
+	leading := 0
+	for leading < len(h.lines) && h.lines[leading].Ope == Keep {
+		leading++
+	}
+	trailing := 0
+	for trailing < len(h.lines)-leading && h.lines[len(h.lines)-1-trailing].Ope == Keep {
+		trailing++
+	}
+	lead = minInt(fuzz, leading)
+	for _, l := range h.lines[lead : len(h.lines)-minInt(fuzz, trailing)] {
+		index := -1
+		if l.inOld() {
+			index = len(oldLines)
+			oldLines = append(oldLines, l.String()+l.terminator())
+		}
+		if l.inNew() {
+			n := l.newSide()
+			newLines = append(newLines, n.String()+n.terminator())
+			if !l.inOld() || l.NewText != "" {
+				index = -1
+			}
+			kept = append(kept, index)
+		}
+	}
+	return
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// matchLines compares lines with pattern. Terminators are not compared.
+func matchLines(lines []string, pos int, pattern []string) bool {
+	for i, p := range pattern {
+		text, _ := splitTerminator(lines[pos+i])
+		if p, _ = splitTerminator(p); text != p {
+			return false
+		}
+	}
+	return true
+}
+
+// patchLines returns new side lines of a hunk applied to matched lines of the old text.
+// Kept lines are copied from the old text. Terminators of other lines are replaced with the terminators
+// that the same terminators of old side lines have in the old text, because "\r" lines look like "\r\n"
+// lines in a patch that has "\n" after each line.
+func patchLines(matched, oldPart, newPart []string, kept []int) []string {
+	endings := make(map[string]string)
+	for i, line := range oldPart {
+		_, from := splitTerminator(line)
+		_, to := splitTerminator(matched[i])
+		if e, ok := endings[from]; ok && e != to {
+			// ambiguous terminators are not replaced
+			to = from
+		}
+		endings[from] = to
+	}
+	result := make([]string, len(newPart))
+	for i, line := range newPart {
+		if kept[i] != -1 {
+			result[i] = matched[kept[i]]
+			continue
+		}
+		text, terminator := splitTerminator(line)
+		if e, ok := endings[terminator]; ok {
+			terminator = e
+		}
+		result[i] = text + terminator
+	}
+	return result
+}
+
+func applyHunks(oldText string, hunks []hunk, fuzz int) (PatchResult, error) {
+	var result PatchResult
+	oldLines := splitLinesWithTerminator(oldText)
+	var newLines []string
+	cursor := 0
+	offset := 0
+	failed := 0
+	for i, h := range hunks {
+		report := HunkResult{
+			Index:             i + 1,
+			OldLineNumber:     h.oldStart,
+			AppliedLineNumber: -1,
+		}
+	search:
+		for f := 0; f <= fuzz; f++ {
+			oldPart, newPart, kept, lead := h.sides(f)
+			expected := h.oldStart - 1 + lead + offset
+			last := len(oldLines) - len(oldPart)
+			for d := 0; d <= len(oldLines); d++ {
+				for _, pos := range []int{expected + d, expected - d} {
+					if pos < cursor || pos > last || !matchLines(oldLines, pos, oldPart) {
+						continue
+					}
+					newLines = append(newLines, oldLines[cursor:pos]...)
+					newLines = append(newLines, patchLines(oldLines[pos:pos+len(oldPart)], oldPart, newPart, kept)...)
+					cursor = pos + len(oldPart)
+					report.AppliedLineNumber = pos - lead + 1
+					report.Offset = report.AppliedLineNumber - h.oldStart
+					report.Fuzz = f
+					offset = report.Offset
+					break search
+				}
+			}
+		}
+		if !report.Applied() {
+			failed++
+		}
+		result.Hunks = append(result.Hunks, report)
+	}
+	newLines = append(newLines, oldLines[cursor:]...)
+	result.Text = strings.Join(newLines, "")
+	if failed > 0 {
+		return result, fmt.Errorf("%d out of %d hunks failed", failed, len(hunks))
+	}
+	return result, nil
+}
+
+// ApplyTo applies changes of the result to oldText and returns the new text.
+//
+// Changes are located by ContextLines lines of context around them, so oldText may differ from the original old text.
+func (r Result) ApplyTo(oldText string) (string, error) {
+	result, err := applyHunks(oldText, r.hunks(ContextLines), 0)
+	return result.Text, err
+}
+
+// Patch applies unified diff text of single file like output of Result.UnifiedWithTag() with PlainTag to oldText.
+//
+// Hunks are searched around their line numbers like GNU patch. fuzz is the maximum number of context lines
+// that can be ignored at each side of hunks.
+// Even if some hunks fail, the result contains the text applied other hunks and reports of all hunks.
+func Patch(oldText, patch string, fuzz int) (PatchResult, error) {
+	parsed, err := ParseUnified(patch, LineByLine)
+	if err != nil {
+		return PatchResult{Text: oldText}, err
+	}
+	switch len(parsed.Files) {
+	case 0:
+		return PatchResult{Text: oldText}, nil
+	case 1:
+		// use all context lines in the patch. hunks are separated by gaps of line numbers.
+		return applyHunks(oldText, parsed.Files[0].hunks(len(parsed.Files[0].Lines)), fuzz)
+	}
+	return PatchResult{Text: oldText}, fmt.Errorf("patch contains %d files", len(parsed.Files))
+}
@@ -0,0 +1,122 @@
+package cdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOldNew(t *testing.T) {
+	for _, diffType := range []DiffType{LineByLine, WordByWord} {
+		diff := Diff(src1, src2, diffType)
+		assert.Equal(t, src1, diff.Old())
+		assert.Equal(t, src2, diff.New())
+	}
+}
+
+var patchBase = "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+var patchModified = "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n11\n12\n13\n14\nfifteen\n16\n"
+
+func TestApplyTo(t *testing.T) {
+	diff := Diff(patchBase, patchModified, WordByWord)
+	result, err := diff.ApplyTo(patchBase)
+	assert.Nil(t, err)
+	assert.Equal(t, patchModified, result)
+
+	// drifted base
+	result, err = diff.ApplyTo("0\n" + patchBase)
+	assert.Nil(t, err)
+	assert.Equal(t, "0\n"+patchModified, result)
+
+	_, err = diff.ApplyTo("a\nb\nc\n")
+	assert.NotNil(t, err)
+}
+
+func TestPatch(t *testing.T) {
+	patch := Diff(patchBase, patchModified, LineByLine).UnifiedWithTag("old", "new", 3, PlainTag)
+
+	t.Run("exact", func(t *testing.T) {
+		result, err := Patch(patchBase, patch, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, patchModified, result.Text)
+		assert.Equal(t, "Hunk #1 succeeded at 2.", result.Hunks[0].String())
+	})
+	t.Run("offset", func(t *testing.T) {
+		result, err := Patch("a\nb\n"+patchBase, patch, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, "a\nb\n"+patchModified, result.Text)
+		assert.Equal(t, []HunkResult{
+			{Index: 1, OldLineNumber: 2, AppliedLineNumber: 4, Offset: 2},
+			{Index: 2, OldLineNumber: 12, AppliedLineNumber: 14, Offset: 2},
+		}, result.Hunks)
+		assert.Equal(t, "Hunk #2 succeeded at 14 (offset 2 lines).", result.Hunks[1].String())
+	})
+	t.Run("fuzz", func(t *testing.T) {
+		drifted := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\nthirteen\n14\n15\n"
+		_, err := Patch(drifted, patch, 0)
+		assert.NotNil(t, err)
+		result, err := Patch(drifted, patch, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n11\n12\nthirteen\n14\nfifteen\n16\n", result.Text)
+		assert.Equal(t, "Hunk #2 succeeded at 12 with fuzz 2.", result.Hunks[1].String())
+	})
+	t.Run("failed", func(t *testing.T) {
+		result, err := Patch("1\n2\n3\n4\n5\n6\n7\n8\n9\n", patch, 0)
+		assert.NotNil(t, err)
+		assert.True(t, result.Hunks[0].Applied())
+		assert.False(t, result.Hunks[1].Applied())
+		assert.Equal(t, "1\n2\n3\n4\nfive\n6\n7\n8\n9\n", result.Text)
+		assert.Equal(t, "Hunk #2 FAILED at 12.", result.Hunks[1].String())
+	})
+}
+
+func TestPatchLineEndings(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+	}{
+		{name: "crlf", oldText: "x\r\nk\r\n", newText: "y\r\nk\r\n"},
+		{name: "crlf to lf", oldText: "a\r\nb\nc\n", newText: "a\nb\nc\n"},
+		{name: "lf to crlf", oldText: "a\nb\n", newText: "a\r\nb\n"},
+		{name: "cr", oldText: "a\rb\rc\r", newText: "a\rx\rc\r"},
+		{name: "cr and crlf", oldText: "a\rb\r\nc\n", newText: "a\rx\r\nc\n"},
+		{name: "lf to cr", oldText: "a\nb\r", newText: "a\rb\r"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, diffType := range []DiffType{LineByLine, WordByWord} {
+				patch := Diff(tt.oldText, tt.newText, diffType).UnifiedWithTag("old", "new", 3, PlainTag)
+				assert.NotContains(t, patch, CarriageReturnMarker)
+				result, err := Patch(tt.oldText, patch, 0)
+				assert.Nil(t, err)
+				assert.Equal(t, tt.newText, result.Text)
+			}
+		})
+	}
+}
+
+func TestPatchEmptyRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		l       int
+		header  string
+	}{
+		{name: "new file", oldText: "", newText: "a\nb\n", l: 3, header: "@@ -0,0 +1,2 @@"},
+		{name: "deleted file", oldText: "a\nb\n", newText: "", l: 3, header: "@@ -1,2 +0,0 @@"},
+		{name: "insertion without context", oldText: "a\nc\n", newText: "a\nb\nc\n", l: 0, header: "@@ -1,0 +2 @@"},
+		{name: "insertions without context", oldText: "a\nc\ne\n", newText: "x\na\nb\nc\nd\ne\n", l: 0, header: "@@ -2,0 +5 @@"},
+		{name: "deletion without context", oldText: "a\nb\nc\n", newText: "a\nc\n", l: 0, header: "@@ -2 +1,0 @@"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := Diff(tt.oldText, tt.newText, LineByLine).UnifiedWithTag("old", "new", tt.l, PlainTag)
+			assert.Contains(t, patch, tt.header+"\n")
+			result, err := Patch(tt.oldText, patch, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.newText, result.Text)
+		})
+	}
+}
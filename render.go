@@ -0,0 +1,351 @@
+package cdiff
+
+import (
+	"io"
+	"time"
+
+	"github.com/gookit/color"
+)
+
+// FileHeader is information of a file passed to Renderer.BeginFile()
+type FileHeader struct {
+	OldTitle string
+	NewTitle string
+	// Headers are extra lines before titles like "diff --git a/file b/file"
+	Headers []string
+	// OldTime and NewTime are modification times written after titles like "diff -u". Zero times are omitted.
+	OldTime time.Time
+	NewTime time.Time
+	// OldMode and NewMode are file modes like "100644" of git.
+	// If only one of them is set, the file exists only on that side like "new file mode 100644".
+	OldMode string
+	NewMode string
+	// OldHash and NewHash are object hashes of "index" line like git. Empty hash of one side is written as "0000000".
+	OldHash string
+	NewHash string
+	// OldEncoding and NewEncoding are text encodings of files. They are written only when both are set and differ.
+	OldEncoding Encoding
+	NewEncoding Encoding
+}
+
+// timeLayout is a layout of timestamps of "diff -u"
+const timeLayout = "2006-01-02 15:04:05.000000000 -0700"
+
+// abbrevHash returns the first 7 characters of hash like git
+func abbrevHash(hash string) string {
+	if hash == "" {
+		return "0000000"
+	}
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// HeaderLines returns Headers and lines of modes and hashes like git, and lines of encodings
+func (h FileHeader) HeaderLines() []string {
+	result := append([]string(nil), h.Headers...)
+	switch {
+	case h.OldMode == "" && h.NewMode != "":
+		result = append(result, "new file mode "+h.NewMode)
+	case h.OldMode != "" && h.NewMode == "":
+		result = append(result, "deleted file mode "+h.OldMode)
+	case h.OldMode != h.NewMode:
+		result = append(result, "old mode "+h.OldMode, "new mode "+h.NewMode)
+	}
+	if h.OldHash != "" || h.NewHash != "" {
+		index := "index " + abbrevHash(h.OldHash) + ".." + abbrevHash(h.NewHash)
+		if h.OldMode != "" && h.OldMode == h.NewMode {
+			index += " " + h.OldMode
+		}
+		result = append(result, index)
+	}
+	if h.OldEncoding != "" && h.NewEncoding != "" && h.OldEncoding != h.NewEncoding {
+		result = append(result, "old encoding "+string(h.OldEncoding), "new encoding "+string(h.NewEncoding))
+	}
+	return result
+}
+
+// Titles returns titles followed by tab separated modification times if they are set
+func (h FileHeader) Titles() (oldTitle, newTitle string) {
+	oldTitle, newTitle = h.OldTitle, h.NewTitle
+	if !h.OldTime.IsZero() {
+		oldTitle += "\t" + h.OldTime.Format(timeLayout)
+	}
+	if !h.NewTime.IsZero() {
+		newTitle += "\t" + h.NewTime.Format(timeLayout)
+	}
+	return
+}
+
+// Hunk is a group of changed lines and kept lines around them
+type Hunk struct {
+	// Header is a hunk header like "@@ -1,3 +1,3 @@"
+	Header string
+	Lines  []Line
+}
+
+// Renderer writes diff to w. Methods are called in this order:
+//
+//	BeginFile
+//	  Hunk
+//	    Line, Fragment (for each fragment), EndLine
+//	    ...
+//	  ...
+//	EndFile
+//
+// Result.Format() calls only Line, Fragment and EndLine.
+// Errors returned by methods stop rendering.
+type Renderer interface {
+	BeginFile(w io.Writer, header FileHeader) error
+	Hunk(w io.Writer, hunk Hunk) error
+	Line(w io.Writer, l Line) error
+	Fragment(w io.Writer, l Line, f Fragment) error
+	EndLine(w io.Writer, l Line) error
+	EndFile(w io.Writer, header FileHeader) error
+}
+
+// themeRenderer is a Renderer that decorates texts with a painter
+type themeRenderer struct {
+	paint painter
+	// lineTag returns texts around lines
+	lineTag func(tag Tag) string
+	// has returns true if the theme has the tag
+	has func(tag Tag) bool
+	// eol is written after each line, hunk header and file header
+	eol string
+	// carriageReturn is written instead of "\r" of changed line endings. If it is empty, "\r" is written as it is.
+	carriageReturn string
+}
+
+// NewTagRenderer returns a Renderer that writes texts of theme around each part
+func NewTagRenderer(theme map[Tag]string) Renderer {
+	return themeRenderer{
+		paint: tagPainter(theme),
+		lineTag: func(tag Tag) string {
+			return theme[tag]
+		},
+		has: func(tag Tag) bool {
+			_, ok := theme[tag]
+			return ok
+		},
+		carriageReturn: theme[CarriageReturn],
+	}
+}
+
+// NewGooKitColorRenderer returns a Renderer that colors each part by gookit/color styles.
+// Styles of whole lines are not used.
+func NewGooKitColorRenderer(theme map[Tag]color.Style) Renderer {
+	return themeRenderer{
+		paint: stylePainter(theme),
+		lineTag: func(tag Tag) string {
+			return ""
+		},
+		has: func(tag Tag) bool {
+			_, ok := theme[tag]
+			return ok
+		},
+		eol:            "\n",
+		carriageReturn: CarriageReturnMarker,
+	}
+}
+
+var (
+	// GooKitColorTagRenderer is a Renderer of GooKitColorTag
+	GooKitColorTagRenderer = NewTagRenderer(GooKitColorTag)
+	// GooKitColorThemeRenderer is a Renderer of GooKitColorTheme
+	GooKitColorThemeRenderer = NewGooKitColorRenderer(GooKitColorTheme)
+	// HTMLTagRenderer is a Renderer of HTMLTag
+	HTMLTagRenderer = NewTagRenderer(HTMLTag)
+)
+
+func (t themeRenderer) write(w io.Writer, text string) error {
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+func (t themeRenderer) BeginFile(w io.Writer, header FileHeader) error {
+	for _, h := range header.HeaderLines() {
+		if err := t.write(w, t.paint(OpenHeader, h)+t.eol); err != nil {
+			return err
+		}
+	}
+	if header.OldTitle == "" && header.NewTitle == "" {
+		return nil
+	}
+	oldTitle, newTitle := header.Titles()
+	return t.write(w, t.paint(OpenHeader, "--- "+oldTitle)+t.eol+t.paint(OpenHeader, "+++ "+newTitle)+t.eol)
+}
+
+func (t themeRenderer) Hunk(w io.Writer, hunk Hunk) error {
+	return t.write(w, t.paint(OpenSection, hunk.Header)+t.eol)
+}
+
+// tags returns tags of not modified and modified fragments of the line
+func (t themeRenderer) tags(l Line) (normal, modified Tag) {
+	return lineTags(l, t.has)
+}
+
+func (t themeRenderer) Line(w io.Writer, l Line) error {
+	normal, _ := t.tags(l)
+	switch l.Ope {
+	case Insert:
+		return t.write(w, t.lineTag(OpenInsertedLine)+t.paint(normal, "+"))
+	case Delete:
+		return t.write(w, t.lineTag(OpenDeletedLine)+t.paint(normal, "-"))
+	}
+	return t.write(w, t.lineTag(OpenKeepLine)+" ")
+}
+
+func (t themeRenderer) Fragment(w io.Writer, l Line, f Fragment) error {
+	if l.Ope == Keep {
+		return t.write(w, f.Text)
+	}
+	normal, modified := t.tags(l)
+	if f.Changed {
+		return t.write(w, t.paint(modified, f.Text))
+	}
+	return t.write(w, t.paint(normal, f.Text))
+}
+
+func (t themeRenderer) EndLine(w io.Writer, l Line) error {
+	switch {
+	case l.Terminator == "":
+	case t.carriageReturn == "":
+		if err := t.write(w, "\r"); err != nil {
+			return err
+		}
+	case l.TerminatorChanged && l.Ope != Keep:
+		_, modified := t.tags(l)
+		if err := t.write(w, t.paint(modified, t.carriageReturn)); err != nil {
+			return err
+		}
+	}
+	var err error
+	switch l.Ope {
+	case Insert:
+		err = t.write(w, t.lineTag(CloseInsertedLine)+t.eol)
+	case Delete:
+		err = t.write(w, t.lineTag(CloseDeletedLine)+t.eol)
+	default:
+		err = t.write(w, t.lineTag(CloseKeepLine)+t.eol)
+	}
+	if err != nil || !l.NoNewline {
+		return err
+	}
+	return t.write(w, t.lineTag(OpenKeepLine)+NoNewlineMarker+t.lineTag(CloseKeepLine)+t.eol)
+}
+
+func (t themeRenderer) EndFile(w io.Writer, header FileHeader) error {
+	return nil
+}
+
+const (
+	// NoNewlineMarker is written after the last line of a text that doesn't end with newline
+	NoNewlineMarker = `\ No newline at end of file`
+	// CarriageReturnMarker is written by console renderers at the end of lines that have "\r\n" or "\r"
+	// only if the other side has a different terminator
+	CarriageReturnMarker = "^M"
+)
+
+// lineTags returns open tags of not modified and modified fragments of the line.
+// Moved lines use moved tags for all fragments if has returns true for them.
+func lineTags(l Line, has func(tag Tag) bool) (normal, modified Tag) {
+	switch l.Ope {
+	case Insert:
+		if l.Move == MovedTo && has(OpenMovedInserted) {
+			return OpenMovedInserted, OpenMovedInserted
+		}
+		return OpenInsertedNotModified, OpenInsertedModified
+	case Delete:
+		if l.Move == MovedFrom && has(OpenMovedDeleted) {
+			return OpenMovedDeleted, OpenMovedDeleted
+		}
+		return OpenDeletedNotModified, OpenDeletedModified
+	}
+	return 0, 0
+}
+
+func renderLines(w io.Writer, lines []Line, renderer Renderer) error {
+	for _, l := range lines {
+		if err := renderer.Line(w, l); err != nil {
+			return err
+		}
+		for _, f := range l.Fragments {
+			if err := renderer.Fragment(w, l, f); err != nil {
+				return err
+			}
+		}
+		if err := renderer.EndLine(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hunkLines returns lines of a hunk. Blank line changes ignored by Options.IgnoreBlankLines are kept lines of only one side.
+// They are not shown alone, but they are written as deleted or inserted lines in hunks that have other changes like "diff -B".
+func hunkLines(lines []Line) []Line {
+	var result []Line
+	for i, l := range lines {
+		if l.Ope != Keep || (l.inOld() && l.inNew()) {
+			continue
+		}
+		if result == nil {
+			result = append([]Line(nil), lines...)
+		}
+		if l.inOld() {
+			result[i].Ope = Delete
+		} else {
+			result[i].Ope = Insert
+		}
+	}
+	if result == nil {
+		return lines
+	}
+	return result
+}
+
+func renderHunk(w io.Writer, lines []Line, b block, renderer Renderer) error {
+	hunk := Hunk{
+		Header: b.section(lines),
+		Lines:  hunkLines(lines[b.start : b.end+1]),
+	}
+	if err := renderer.Hunk(w, hunk); err != nil {
+		return err
+	}
+	return renderLines(w, hunk.Lines, renderer)
+}
+
+func renderFile(w io.Writer, lines []Line, header FileHeader, l int, renderer Renderer) error {
+	if err := renderer.BeginFile(w, header); err != nil {
+		return err
+	}
+	for _, b := range grouping(lines, l) {
+		if err := renderHunk(w, lines, b, renderer); err != nil {
+			return err
+		}
+	}
+	return renderer.EndFile(w, header)
+}
+
+// Render writes unified diff with renderer. l is the number of kept lines around changes.
+func (r Result) Render(w io.Writer, oldTitle, newTitle string, l int, renderer Renderer) error {
+	return r.RenderWithHeader(w, FileHeader{OldTitle: oldTitle, NewTitle: newTitle}, l, renderer)
+}
+
+// RenderWithHeader is like Render, but it writes header that has timestamps, modes and hashes
+func (r Result) RenderWithHeader(w io.Writer, header FileHeader, l int, renderer Renderer) error {
+	return renderFile(w, r.Lines, header, l, renderer)
+}
+
+// Render writes unified diff of all files with renderer
+func (m MultiResult) Render(w io.Writer, l int, renderer Renderer) error {
+	for _, file := range m.Files {
+		header := FileHeader{OldTitle: file.OldName, NewTitle: file.NewName, Headers: file.Headers}
+		if err := renderFile(w, file.Lines, header, l, renderer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
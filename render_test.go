@@ -0,0 +1,127 @@
+package cdiff
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/stretchr/testify/assert"
+)
+
+// markdownRenderer is a custom renderer that writes a diff code block
+type markdownRenderer struct{}
+
+func (markdownRenderer) BeginFile(w io.Writer, header FileHeader) error {
+	_, err := io.WriteString(w, "*"+header.OldTitle+" → "+header.NewTitle+"*\n```diff\n")
+	return err
+}
+
+func (markdownRenderer) Hunk(w io.Writer, hunk Hunk) error {
+	_, err := io.WriteString(w, hunk.Header+"\n")
+	return err
+}
+
+func (markdownRenderer) Line(w io.Writer, l Line) error {
+	mark := map[Ope]string{Insert: "+", Delete: "-", Keep: " "}[l.Ope]
+	_, err := io.WriteString(w, mark)
+	return err
+}
+
+func (markdownRenderer) Fragment(w io.Writer, l Line, f Fragment) error {
+	_, err := io.WriteString(w, f.Text)
+	return err
+}
+
+func (markdownRenderer) EndLine(w io.Writer, l Line) error {
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (markdownRenderer) EndFile(w io.Writer, header FileHeader) error {
+	_, err := io.WriteString(w, "```\n")
+	return err
+}
+
+func TestRender(t *testing.T) {
+	diff := Diff(src1, src2, WordByWord)
+	var builder strings.Builder
+	assert.Nil(t, diff.Render(&builder, "olddoc", "newdoc", 1, markdownRenderer{}))
+	assert.Equal(t, "*olddoc → newdoc*\n```diff\n@@ -1,3 +1,3 @@\n     abc\n-    def\n+    deg\n     ghi\n```\n", builder.String())
+}
+
+func TestBuiltinRenderers(t *testing.T) {
+	diff := Diff(src1, src2, WordByWord)
+	var builder strings.Builder
+	assert.Nil(t, diff.Render(&builder, "olddoc", "newdoc", 1, GooKitColorTagRenderer))
+	assert.Equal(t, diff.UnifiedWithTag("olddoc", "newdoc", 1, GooKitColorTag), builder.String())
+
+	builder.Reset()
+	assert.Nil(t, diff.Render(&builder, "olddoc", "newdoc", 1, GooKitColorThemeRenderer))
+	assert.Equal(t, expectedResult, color.ClearCode(builder.String()))
+
+	builder.Reset()
+	assert.Nil(t, diff.Render(&builder, "olddoc", "newdoc", 1, HTMLTagRenderer))
+	assert.Contains(t, builder.String(), `<div style="background-color: #ffecec;">-    de<span style="background-color: #f8cbcb;">f</span></div>`)
+}
+
+func TestFileHeader(t *testing.T) {
+	oldTime := time.Date(2020, 1, 2, 3, 4, 5, 6, time.FixedZone("JST", 9*60*60))
+	newTime := time.Date(2020, 2, 3, 4, 5, 6, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header FileHeader
+		want   string
+	}{
+		{
+			name:   "titles",
+			header: FileHeader{OldTitle: "old.txt", NewTitle: "new.txt"},
+			want:   "--- old.txt\n+++ new.txt\n",
+		},
+		{
+			name:   "timestamps",
+			header: FileHeader{OldTitle: "old.txt", NewTitle: "new.txt", OldTime: oldTime, NewTime: newTime},
+			want:   "--- old.txt\t2020-01-02 03:04:05.000000006 +0900\n+++ new.txt\t2020-02-03 04:05:06.000000000 +0000\n",
+		},
+		{
+			name: "hashes",
+			header: FileHeader{OldTitle: "a/x", NewTitle: "b/x", Headers: []string{"diff --git a/x b/x"},
+				OldMode: "100644", NewMode: "100644", OldHash: "1234567890abcdef", NewHash: "fedcba0987654321"},
+			want: "diff --git a/x b/x\nindex 1234567..fedcba0 100644\n--- a/x\n+++ b/x\n",
+		},
+		{
+			name: "mode change",
+			header: FileHeader{OldTitle: "a/x", NewTitle: "b/x", Headers: []string{"diff --git a/x b/x"},
+				OldMode: "100644", NewMode: "100755", OldHash: "1234567890abcdef", NewHash: "fedcba0987654321"},
+			want: "diff --git a/x b/x\nold mode 100644\nnew mode 100755\nindex 1234567..fedcba0\n--- a/x\n+++ b/x\n",
+		},
+		{
+			name:   "new file",
+			header: FileHeader{OldTitle: "/dev/null", NewTitle: "b/x", NewMode: "100644", NewHash: "fedcba0987654321"},
+			want:   "new file mode 100644\nindex 0000000..fedcba0\n--- /dev/null\n+++ b/x\n",
+		},
+		{
+			name:   "deleted file",
+			header: FileHeader{OldTitle: "a/x", NewTitle: "/dev/null", OldMode: "100644", OldHash: "1234567890abcdef"},
+			want:   "deleted file mode 100644\nindex 1234567..0000000\n--- a/x\n+++ /dev/null\n",
+		},
+		{
+			name:   "encodings",
+			header: FileHeader{OldTitle: "old.txt", NewTitle: "new.txt", OldEncoding: ShiftJIS, NewEncoding: UTF8},
+			want:   "old encoding Shift_JIS\nnew encoding UTF-8\n--- old.txt\n+++ new.txt\n",
+		},
+		{
+			name:   "same encodings",
+			header: FileHeader{OldTitle: "old.txt", NewTitle: "new.txt", OldEncoding: UTF8, NewEncoding: UTF8},
+			want:   "--- old.txt\n+++ new.txt\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var builder strings.Builder
+			assert.Nil(t, NewTagRenderer(PlainTag).BeginFile(&builder, tt.header))
+			assert.Equal(t, tt.want, builder.String())
+		})
+	}
+}
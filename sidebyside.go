@@ -0,0 +1,248 @@
+package cdiff
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	sideBySideSeparator = " | "
+	tabWidth            = 8
+)
+
+// sideBySideRow is a pair of old and new lines shown in the same row. nil means a blank cell.
+type sideBySideRow struct {
+	old *Line
+	new *Line
+}
+
+func pairLines(lines []Line) []sideBySideRow {
+	var rows []sideBySideRow
+	for i := 0; i < len(lines); {
+		if lines[i].Ope == Keep {
+			var row sideBySideRow
+			if lines[i].inOld() {
+				row.old = &lines[i]
+			}
+			if lines[i].inNew() {
+				newSide := lines[i].newSide()
+				row.new = &newSide
+			}
+			rows = append(rows, row)
+			i++
+			continue
+		}
+		var deleted, inserted []*Line
+		for ; i < len(lines) && lines[i].Ope == Delete; i++ {
+			deleted = append(deleted, &lines[i])
+		}
+		for ; i < len(lines) && lines[i].Ope == Insert; i++ {
+			inserted = append(inserted, &lines[i])
+		}
+		for j := 0; j < len(deleted) || j < len(inserted); j++ {
+			var row sideBySideRow
+			if j < len(deleted) {
+				row.old = deleted[j]
+			}
+			if j < len(inserted) {
+				row.new = inserted[j]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// cellRow is one visual row of a cell. width is the display width of the fragments.
+type cellRow struct {
+	fragments []Fragment
+	width     int
+}
+
+// splitFragments splits fragments into rows that fit in width.
+// If wrap is false, only the first row is returned and the rest is truncated.
+func splitFragments(fragments []Fragment, width int, wrap bool) []cellRow {
+	rows := []cellRow{{}}
+	current := &rows[0]
+	for _, f := range fragments {
+		var builder strings.Builder
+		flush := func() {
+			if builder.Len() > 0 {
+				current.fragments = append(current.fragments, Fragment{Text: builder.String(), Changed: f.Changed})
+				builder.Reset()
+			}
+		}
+		for _, r := range f.Text {
+			text := string(r)
+			w := runewidth.RuneWidth(r)
+			if r == '\t' {
+				w = tabWidth - current.width%tabWidth
+				text = strings.Repeat(" ", w)
+			}
+			if current.width+w > width && current.width > 0 {
+				flush()
+				if !wrap {
+					return rows
+				}
+				rows = append(rows, cellRow{})
+				current = &rows[len(rows)-1]
+				if r == '\t' {
+					w = tabWidth
+					text = strings.Repeat(" ", w)
+				}
+			}
+			builder.WriteString(text)
+			current.width += w
+		}
+		flush()
+	}
+	return rows
+}
+
+type sideBySideLayout struct {
+	numberWidth int
+	columnWidth int
+	textWidth   int
+	wrap        bool
+	// has returns true if the theme has the tag
+	has func(tag Tag) bool
+}
+
+func newSideBySideLayout(lines []Line, width int, wrap bool) sideBySideLayout {
+	maxNumber := 0
+	for _, l := range lines {
+		if l.OldLineNumber > maxNumber {
+			maxNumber = l.OldLineNumber
+		}
+		if l.NewLineNumber > maxNumber {
+			maxNumber = l.NewLineNumber
+		}
+	}
+	layout := sideBySideLayout{
+		numberWidth: len(strconv.Itoa(maxNumber)),
+		wrap:        wrap,
+	}
+	layout.columnWidth = (width - len(sideBySideSeparator)) / 2
+	// line number, space and +/- mark
+	layout.textWidth = layout.columnWidth - layout.numberWidth - 2
+	if layout.textWidth < 1 {
+		layout.textWidth = 1
+		layout.columnWidth = layout.numberWidth + 3
+	}
+	return layout
+}
+
+func (s sideBySideLayout) cells(l *Line, number int, pad bool, paint painter) []string {
+	if l == nil {
+		return nil
+	}
+	mark := " "
+	switch l.Ope {
+	case Delete:
+		mark = "-"
+	case Insert:
+		mark = "+"
+	}
+	normalTag, modifiedTag := lineTags(*l, s.has)
+	rows := splitFragments(l.Fragments, s.textWidth, s.wrap)
+	result := make([]string, len(rows))
+	for i, row := range rows {
+		var builder strings.Builder
+		if i == 0 {
+			builder.WriteString(strings.Repeat(" ", s.numberWidth-len(strconv.Itoa(number))))
+			builder.WriteString(strconv.Itoa(number))
+		} else {
+			builder.WriteString(strings.Repeat(" ", s.numberWidth))
+		}
+		builder.WriteString(" ")
+		builder.WriteString(paint(normalTag, mark))
+		for _, f := range row.fragments {
+			if f.Changed {
+				builder.WriteString(paint(modifiedTag, f.Text))
+			} else {
+				builder.WriteString(paint(normalTag, f.Text))
+			}
+		}
+		if pad {
+			builder.WriteString(strings.Repeat(" ", s.textWidth-row.width))
+		}
+		result[i] = builder.String()
+	}
+	return result
+}
+
+func (s sideBySideLayout) header(oldText, newText string) string {
+	return runewidth.FillRight(runewidth.Truncate(oldText, s.columnWidth, ""), s.columnWidth) +
+		sideBySideSeparator + runewidth.Truncate(newText, s.columnWidth, "")
+}
+
+func (s sideBySideLayout) format(lines []Line, builder *strings.Builder, paint painter) {
+	for _, row := range pairLines(lines) {
+		var oldCells, newCells []string
+		if row.old != nil {
+			oldCells = s.cells(row.old, row.old.OldLineNumber, true, paint)
+		}
+		if row.new != nil {
+			newCells = s.cells(row.new, row.new.NewLineNumber, false, paint)
+		}
+		for i := 0; i < len(oldCells) || i < len(newCells); i++ {
+			if i < len(oldCells) {
+				builder.WriteString(oldCells[i])
+			} else {
+				builder.WriteString(strings.Repeat(" ", s.columnWidth))
+			}
+			if i < len(newCells) {
+				builder.WriteString(sideBySideSeparator)
+				builder.WriteString(newCells[i])
+			} else {
+				builder.WriteString(strings.TrimRight(sideBySideSeparator, " "))
+			}
+			builder.WriteString("\n")
+		}
+	}
+}
+
+// SideBySideWithTag returns side-by-side (split) formatted diff text.
+//
+// width is the total width of each output row. Long lines are wrapped if wrap is true, otherwise they are truncated.
+func (r Result) SideBySideWithTag(oldTitle, newTitle string, l, width int, wrap bool, theme map[Tag]string) string {
+	var builder strings.Builder
+	layout := newSideBySideLayout(r.Lines, width, wrap)
+	layout.has = func(tag Tag) bool {
+		_, ok := theme[tag]
+		return ok
+	}
+	builder.WriteString(theme[OpenHeader])
+	builder.WriteString(layout.header("--- "+oldTitle, "+++ "+newTitle))
+	builder.WriteString(theme[CloseHeader])
+	blocks := grouping(r.Lines, l)
+	for _, block := range blocks {
+		builder.WriteString(theme[OpenSection])
+		builder.WriteString(block.section(r.Lines))
+		builder.WriteString(theme[CloseSection])
+		layout.format(r.Lines[block.start:block.end+1], &builder, tagPainter(theme))
+	}
+	return builder.String()
+}
+
+// SideBySideWithGooKitColor returns side-by-side (split) formatted diff text colored by gookit/color styles.
+//
+// width is the total width of each output row. Long lines are wrapped if wrap is true, otherwise they are truncated.
+func (r Result) SideBySideWithGooKitColor(oldTitle, newTitle string, l, width int, wrap bool, theme map[Tag]color.Style) string {
+	var builder strings.Builder
+	layout := newSideBySideLayout(r.Lines, width, wrap)
+	layout.has = func(tag Tag) bool {
+		_, ok := theme[tag]
+		return ok
+	}
+	builder.WriteString(theme[OpenHeader].Sprint(layout.header("--- "+oldTitle, "+++ "+newTitle)) + "\n")
+	blocks := grouping(r.Lines, l)
+	for _, block := range blocks {
+		builder.WriteString(theme[OpenSection].Sprint(block.section(r.Lines)) + "\n")
+		layout.format(r.Lines[block.start:block.end+1], &builder, stylePainter(theme))
+	}
+	return builder.String()
+}
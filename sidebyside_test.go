@@ -0,0 +1,81 @@
+package cdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPairLines(t *testing.T) {
+	lines := []Line{
+		{Ope: Keep, OldLineNumber: 1, NewLineNumber: 1},
+		{Ope: Delete, OldLineNumber: 2, NewLineNumber: -1},
+		{Ope: Delete, OldLineNumber: 3, NewLineNumber: -1},
+		{Ope: Insert, OldLineNumber: -1, NewLineNumber: 2},
+		{Ope: Keep, OldLineNumber: 4, NewLineNumber: 3},
+		{Ope: Insert, OldLineNumber: -1, NewLineNumber: 4},
+	}
+	rows := pairLines(lines)
+	assert.Equal(t, []sideBySideRow{
+		{old: &lines[0], new: &lines[0]},
+		{old: &lines[1], new: &lines[3]},
+		{old: &lines[2], new: nil},
+		{old: &lines[4], new: &lines[4]},
+		{old: nil, new: &lines[5]},
+	}, rows)
+}
+
+func TestSplitFragments(t *testing.T) {
+	fragments := []Fragment{
+		{Text: "abc"},
+		{Text: "def", Changed: true},
+		{Text: "g"},
+	}
+	t.Run("fit", func(t *testing.T) {
+		assert.Equal(t, []cellRow{{fragments: fragments, width: 7}}, splitFragments(fragments, 10, false))
+	})
+	t.Run("truncate", func(t *testing.T) {
+		assert.Equal(t, []cellRow{
+			{fragments: []Fragment{{Text: "abc"}, {Text: "d", Changed: true}}, width: 4},
+		}, splitFragments(fragments, 4, false))
+	})
+	t.Run("wrap", func(t *testing.T) {
+		assert.Equal(t, []cellRow{
+			{fragments: []Fragment{{Text: "abc"}, {Text: "d", Changed: true}}, width: 4},
+			{fragments: []Fragment{{Text: "ef", Changed: true}, {Text: "g"}}, width: 3},
+		}, splitFragments(fragments, 4, true))
+	})
+	t.Run("wide characters", func(t *testing.T) {
+		assert.Equal(t, []cellRow{
+			{fragments: []Fragment{{Text: "日本"}}, width: 4},
+			{fragments: []Fragment{{Text: "語"}}, width: 2},
+		}, splitFragments([]Fragment{{Text: "日本語"}}, 5, true))
+	})
+}
+
+var expectedSideBySide = `--- olddoc                 | +++ newdoc
+@@ -1,3 +1,3 @@
+1      abc                 | 1      abc
+2 -    def                 | 2 +    deg
+3      ghi                 | 3      ghi
+`
+
+func TestSideBySide(t *testing.T) {
+	diff := Diff(src1, src2, WordByWord)
+	result := diff.SideBySideWithTag("olddoc", "newdoc", 1, 55, false, HTMLTag)
+	assert.Contains(t, result, `<span style="background-color: #a6f3a6;">g</span>`)
+	plain := diff.SideBySideWithTag("olddoc", "newdoc", 1, 55, false, map[Tag]string{
+		CloseHeader:  "\n",
+		CloseSection: "\n",
+	})
+	assert.Equal(t, expectedSideBySide, plain)
+}
+
+func TestSideBySideIgnoredChanges(t *testing.T) {
+	diff := DiffWithOptions("a  b\r\nc\n", "a b\nx\n", Options{Type: LineByLine, IgnoreSpaceChange: true, IgnoreLineEnding: true})
+	result := diff.SideBySideWithTag("old", "new", 1, 40, false, map[Tag]string{
+		CloseHeader:  "\n",
+		CloseSection: "\n",
+	})
+	assert.Contains(t, result, "1  a  b            | 1  a b\n")
+}
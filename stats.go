@@ -0,0 +1,43 @@
+package cdiff
+
+import "unicode/utf8"
+
+// Stats is a summary of Result
+type Stats struct {
+	Inserted int
+	Deleted  int
+	Kept     int
+	// InsertedChars and DeletedChars are counts of characters in changed fragments
+	InsertedChars int
+	DeletedChars  int
+	// Hunks is the count of hunks grouped with ContextLines kept lines
+	Hunks int
+}
+
+// Stats returns counts of lines, changed characters and hunks
+func (r Result) Stats() Stats {
+	var result Stats
+	for _, l := range r.Lines {
+		switch l.Ope {
+		case Insert:
+			result.Inserted++
+		case Delete:
+			result.Deleted++
+		case Keep:
+			result.Kept++
+		}
+		for _, f := range l.Fragments {
+			if !f.Changed {
+				continue
+			}
+			switch l.Ope {
+			case Insert:
+				result.InsertedChars += utf8.RuneCountInString(f.Text)
+			case Delete:
+				result.DeletedChars += utf8.RuneCountInString(f.Text)
+			}
+		}
+	}
+	result.Hunks = len(grouping(r.Lines, ContextLines))
+	return result
+}
@@ -0,0 +1,21 @@
+package cdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	assert.Equal(t, Stats{Inserted: 1, Deleted: 1, Kept: 2, InsertedChars: 1, DeletedChars: 1, Hunks: 1}, Diff(src1, src2, WordByWord).Stats())
+	assert.Equal(t, Stats{Inserted: 1, Deleted: 1, Kept: 2, Hunks: 1}, Diff(src1, src2, LineByLine).Stats())
+	assert.Equal(t, Stats{Kept: 2}, Diff("a\nb\n", "a\nb\n", WordByWord).Stats())
+
+	var oldText, newText string
+	for i := 0; i < 20; i++ {
+		oldText += "line\n"
+		newText += "line\n"
+	}
+	result := Diff("x\n"+oldText+"y\n", "X\n"+newText+"Y\n", WordByWord)
+	assert.Equal(t, Stats{Inserted: 2, Deleted: 2, Kept: 20, InsertedChars: 2, DeletedChars: 2, Hunks: 2}, result.Stats())
+}
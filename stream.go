@@ -0,0 +1,409 @@
+package cdiff
+
+import (
+	"bufio"
+	"bytes"
+	"hash/fnv"
+	"io"
+	"strings"
+)
+
+// streamWindow is the number of lines of each side that DiffReader() compares at once
+const streamWindow = 4096
+
+// streamLine is a line read by lineReader. Lines are compared by hash of normalized text.
+type streamLine struct {
+	text string
+	hash uint64
+	// blank is true if the normalized line has only white spaces
+	blank bool
+	// empty is true if the normalized line is empty
+	empty bool
+}
+
+// lineReader reads lines and keeps lines that are not consumed yet
+type lineReader struct {
+	reader  *bufio.Reader
+	options Options
+	eof     bool
+	lines   []streamLine
+}
+
+func newLineReader(reader io.Reader, options Options) *lineReader {
+	return &lineReader{
+		reader:  bufio.NewReader(reader),
+		options: options,
+	}
+}
+
+// readLine reads a line that ends with "\n", "\r\n" or "\r" like splitLinesWithTerminator().
+// The line keeps its terminator. It returns io.EOF with the last line at the end of input.
+func (r *lineReader) readLine() (string, error) {
+	var line []byte
+	for {
+		if r.reader.Buffered() == 0 {
+			if _, err := r.reader.Peek(1); err != nil {
+				return string(line), err
+			}
+		}
+		buffer, _ := r.reader.Peek(r.reader.Buffered())
+		i := bytes.IndexAny(buffer, "\r\n")
+		if i == -1 {
+			line = append(line, buffer...)
+			r.reader.Discard(len(buffer))
+			continue
+		}
+		cr := buffer[i] == '\r'
+		line = append(line, buffer[:i+1]...)
+		r.reader.Discard(i + 1)
+		if !cr {
+			return string(line), nil
+		}
+		// "\n" of "\r\n" may be in the next chunk
+		if next, err := r.reader.Peek(1); err == nil && next[0] == '\n' {
+			line = append(line, '\n')
+			r.reader.Discard(1)
+		}
+		return string(line), nil
+	}
+}
+
+// fill reads lines until it has n lines or reaches end of input.
+func (r *lineReader) fill(n int) error {
+	for !r.eof && len(r.lines) < n {
+		line, err := r.readLine()
+		if err == io.EOF {
+			r.eof = true
+		} else if err != nil {
+			return err
+		}
+		if line == "" {
+			continue
+		}
+		key := r.options.normalize(line)
+		hash := fnv.New64a()
+		hash.Write([]byte(key))
+		r.lines = append(r.lines, streamLine{
+			text:  line,
+			hash:  hash.Sum64(),
+			blank: strings.TrimSpace(key) == "",
+			empty: key == "\n",
+		})
+	}
+	return nil
+}
+
+// streamDiffer compares texts window by window
+type streamDiffer struct {
+	old           *lineReader
+	new           *lineReader
+	options       Options
+	window        int
+	oldLineNumber int
+	newLineNumber int
+	callback      func(l Line) error
+}
+
+// ids converts hashes of lines into ids for lineDiffer
+func (s *streamDiffer) ids(a, b []streamLine) (aIDs, bIDs []int, blankID int, blankIDs map[int]bool) {
+	ids := make(map[uint64]int)
+	blankID = -1
+	if s.options.IgnoreBlankLines {
+		blankIDs = make(map[int]bool)
+	}
+	toIDs := func(lines []streamLine) []int {
+		result := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line.hash]
+			if !ok {
+				id = len(ids)
+				ids[line.hash] = id
+			}
+			if line.empty {
+				blankID = id
+			}
+			if blankIDs != nil && line.blank {
+				blankIDs[id] = true
+			}
+			result[i] = id
+		}
+		return result
+	}
+	aIDs = toIDs(a)
+	bIDs = toIDs(b)
+	return
+}
+
+func (s *streamDiffer) diff(a, b []streamLine) []Ope {
+	aIDs, bIDs, blankID, _ := s.ids(a, b)
+	return diffIDs(aIDs, bIDs, s.options.Type&algorithmMask, blankID)
+}
+
+// lastAnchor returns the last line of the longest sequence of lines that are unique in both windows
+func lastAnchor(a, b []streamLine) (aPos, bPos int, ok bool) {
+	type occurrence struct {
+		aCount, bCount int
+		bPos           int
+	}
+	occurrences := make(map[uint64]*occurrence)
+	for _, line := range a {
+		o, ok := occurrences[line.hash]
+		if !ok {
+			o = &occurrence{}
+			occurrences[line.hash] = o
+		}
+		o.aCount++
+	}
+	for i, line := range b {
+		if o, ok := occurrences[line.hash]; ok {
+			o.bCount++
+			o.bPos = i
+		}
+	}
+	var aPositions, bPositions []int
+	for i, line := range a {
+		if o := occurrences[line.hash]; o.aCount == 1 && o.bCount == 1 {
+			aPositions = append(aPositions, i)
+			bPositions = append(bPositions, o.bPos)
+		}
+	}
+	if len(aPositions) == 0 {
+		return 0, 0, false
+	}
+	// tails[k] is the index of the smallest last element of increasing sequences of length k+1
+	var tails []int
+	for i, pos := range bPositions {
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if bPositions[tails[mid]] < pos {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+	last := tails[len(tails)-1]
+	return aPositions[last], bPositions[last], true
+}
+
+// emit converts the edit script of the first lines of both sides into Line values and consumes them
+func (s *streamDiffer) emit(ops []Ope) error {
+	oldCount, newCount := 0, 0
+	for _, ope := range ops {
+		if ope != Insert {
+			oldCount++
+		}
+		if ope != Delete {
+			newCount++
+		}
+	}
+	a := s.old.lines[:oldCount]
+	b := s.new.lines[:newCount]
+	aIDs, bIDs, _, blankIDs := s.ids(a, b)
+	oldTexts := make([]string, len(a))
+	for i, line := range a {
+		oldTexts[i] = line.text
+	}
+	newTexts := make([]string, len(b))
+	for i, line := range b {
+		newTexts[i] = line.text
+	}
+	blocks := opsToBlocks(ops, oldTexts, newTexts, aIDs, bIDs, blankIDs, s.oldLineNumber, s.newLineNumber)
+	var lines []Line
+	if s.options.Type&granularityMask == LineByLine {
+		lines = lineDiffBlocks(blocks)
+	} else {
+		lines = wordDiffBlocks(blocks, s.options)
+	}
+	markTerminatorChanges(lines)
+	if s.options.DetectMoves {
+		detectMoves(lines)
+	}
+	for _, l := range lines {
+		if err := s.callback(l); err != nil {
+			return err
+		}
+	}
+	s.oldLineNumber += oldCount
+	s.newLineNumber += newCount
+	s.old.lines = s.old.lines[oldCount:]
+	s.new.lines = s.new.lines[newCount:]
+	return nil
+}
+
+func (s *streamDiffer) run() error {
+	for {
+		if err := s.old.fill(s.window); err != nil {
+			return err
+		}
+		if err := s.new.fill(s.window); err != nil {
+			return err
+		}
+		a, b := s.old.lines, s.new.lines
+		if len(a) == 0 && len(b) == 0 {
+			return nil
+		}
+		prefix := 0
+		for prefix < len(a) && prefix < len(b) && a[prefix].hash == b[prefix].hash {
+			prefix++
+		}
+		var ops []Ope
+		if prefix > 0 {
+			ops = make([]Ope, prefix)
+			for i := range ops {
+				ops[i] = Keep
+			}
+		} else if s.old.eof && s.new.eof {
+			ops = s.diff(a, b)
+		} else if aPos, bPos, ok := lastAnchor(a, b); ok {
+			ops = append(s.diff(a[:aPos], b[:bPos]), Keep)
+		} else {
+			ops = cutOps(s.diff(a, b), s.window/2)
+		}
+		if err := s.emit(ops); err != nil {
+			return err
+		}
+	}
+}
+
+// cutOps returns the first part of the edit script that consumes about limit lines of either side.
+// It is cut after a kept line if possible.
+func cutOps(ops []Ope, limit int) []Ope {
+	oldCount, newCount := 0, 0
+	end := len(ops)
+	for i, ope := range ops {
+		if ope != Insert {
+			oldCount++
+		}
+		if ope != Delete {
+			newCount++
+		}
+		if oldCount >= limit || newCount >= limit {
+			end = i + 1
+			break
+		}
+	}
+	for i := end; i > 0; i-- {
+		if ops[i-1] == Keep {
+			return ops[:i]
+		}
+	}
+	return ops[:end]
+}
+
+func diffReader(oldReader, newReader io.Reader, options Options, window int, callback func(l Line) error) error {
+	s := &streamDiffer{
+		old:           newLineReader(oldReader, options),
+		new:           newLineReader(newReader, options),
+		options:       options,
+		window:        window,
+		oldLineNumber: 1,
+		newLineNumber: 1,
+		callback:      callback,
+	}
+	return s.run()
+}
+
+// DiffReader calcs diff of texts from readers and calls callback with each line in order
+//
+// It is for very large texts. Lines are compared by hash values, and texts are compared window by window
+// split at lines that are unique in both windows, so memory usage doesn't depend on the size of texts.
+// The result can be different from Diff() if lines are moved far away.
+// If options.DetectMoves is true, moved lines are detected within each window.
+// If callback returns an error, DiffReader stops and returns it.
+func DiffReader(oldReader, newReader io.Reader, options Options, callback func(l Line) error) error {
+	return diffReader(oldReader, newReader, options, streamWindow, callback)
+}
+
+// unifiedWriter writes hunks of unified diff as soon as they are closed
+type unifiedWriter struct {
+	writer   io.Writer
+	l        int
+	renderer Renderer
+	// context is kept lines before the next hunk
+	context []Line
+	hunk    []Line
+	// trailing is the count of kept lines at the end of the hunk
+	trailing int
+}
+
+func (u *unifiedWriter) writeHunk(lines []Line) error {
+	return renderHunk(u.writer, lines, block{start: 0, end: len(lines) - 1}, u.renderer)
+}
+
+func (u *unifiedWriter) add(l Line) error {
+	if l.Ope != Keep {
+		if u.hunk == nil {
+			u.hunk = u.context
+			u.context = nil
+		}
+		u.hunk = append(u.hunk, l)
+		u.trailing = 0
+		return nil
+	}
+	if u.hunk == nil {
+		u.context = append(u.context, l)
+		if len(u.context) > u.l {
+			u.context = u.context[1:]
+		}
+		return nil
+	}
+	u.hunk = append(u.hunk, l)
+	u.trailing++
+	if u.trailing < u.l*2 {
+		return nil
+	}
+	end := len(u.hunk) - u.trailing + u.l
+	u.context = append([]Line(nil), u.hunk[len(u.hunk)-u.l:]...)
+	err := u.writeHunk(u.hunk[:end])
+	u.hunk = nil
+	return err
+}
+
+func (u *unifiedWriter) close() error {
+	if u.hunk == nil {
+		return nil
+	}
+	trailing := u.trailing
+	if trailing > u.l {
+		trailing = u.l
+	}
+	return u.writeHunk(u.hunk[:len(u.hunk)-u.trailing+trailing])
+}
+
+// UnifiedReader writes unified diff text of texts from readers to w incrementally
+//
+// It uses DiffReader(). Only the lines of the current hunk are kept in memory.
+func UnifiedReader(w io.Writer, oldReader, newReader io.Reader, oldTitle, newTitle string, l int, options Options, theme map[Tag]string) error {
+	return RenderReader(w, oldReader, newReader, oldTitle, newTitle, l, options, NewTagRenderer(theme))
+}
+
+// RenderReader is like UnifiedReader, but it writes with renderer
+func RenderReader(w io.Writer, oldReader, newReader io.Reader, oldTitle, newTitle string, l int, options Options, renderer Renderer) error {
+	return renderReader(w, oldReader, newReader, FileHeader{OldTitle: oldTitle, NewTitle: newTitle}, l, options, streamWindow, renderer)
+}
+
+// RenderReaderWithHeader is like RenderReader, but it writes header that has timestamps, modes and hashes
+func RenderReaderWithHeader(w io.Writer, oldReader, newReader io.Reader, header FileHeader, l int, options Options, renderer Renderer) error {
+	return renderReader(w, oldReader, newReader, header, l, options, streamWindow, renderer)
+}
+
+func renderReader(w io.Writer, oldReader, newReader io.Reader, header FileHeader, l int, options Options, window int, renderer Renderer) error {
+	if err := renderer.BeginFile(w, header); err != nil {
+		return err
+	}
+	u := &unifiedWriter{writer: w, l: l, renderer: renderer}
+	if err := diffReader(oldReader, newReader, options, window, u.add); err != nil {
+		return err
+	}
+	if err := u.close(); err != nil {
+		return err
+	}
+	return renderer.EndFile(w, header)
+}
@@ -0,0 +1,175 @@
+package cdiff
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collectLines(t *testing.T, oldText, newText string, options Options, window int) Result {
+	var result Result
+	err := diffReader(strings.NewReader(oldText), strings.NewReader(newText), options, window, func(l Line) error {
+		result.Lines = append(result.Lines, l)
+		return nil
+	})
+	assert.Nil(t, err)
+	return result
+}
+
+func TestDiffReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldText  string
+		newText  string
+		diffType DiffType
+	}{
+		{
+			name:     "line by line",
+			oldText:  src1,
+			newText:  src2,
+			diffType: LineByLine,
+		},
+		{
+			name:     "word by word",
+			oldText:  src1,
+			newText:  src2,
+			diffType: WordByWord,
+		},
+		{
+			name:     "insert only",
+			oldText:  "a\nb\n",
+			newText:  "a\nx\nb\n",
+			diffType: LineByLine,
+		},
+		{
+			name:     "empty old text",
+			oldText:  "",
+			newText:  "a\nb\n",
+			diffType: LineByLine,
+		},
+		{
+			name:     "line endings",
+			oldText:  "a\r\nb\rc\n",
+			newText:  "a\nb\rc\r\n",
+			diffType: WordByWord,
+		},
+		{
+			name:     "no newline at end of file",
+			oldText:  "a\nb",
+			newText:  "a\nb\nc",
+			diffType: WordByWord,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := collectLines(t, tt.oldText, tt.newText, Options{Type: tt.diffType}, streamWindow)
+			assert.Equal(t, dumpForTest(Diff(tt.oldText, tt.newText, tt.diffType)), dumpForTest(got))
+			assert.Equal(t, tt.oldText, got.Old())
+			assert.Equal(t, tt.newText, got.New())
+		})
+	}
+}
+
+func TestDiffReaderWindows(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 100; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("line %d\n", i))
+		if i%10 == 3 {
+			newLines = append(newLines, fmt.Sprintf("changed %d\n", i))
+		} else {
+			newLines = append(newLines, fmt.Sprintf("line %d\n", i))
+		}
+	}
+	oldText := strings.Join(oldLines, "")
+	newText := strings.Join(newLines, "")
+	got := collectLines(t, oldText, newText, Options{Type: LineByLine}, 8)
+	assert.Equal(t, dumpForTest(Diff(oldText, newText, LineByLine)), dumpForTest(got))
+	for i, l := range got.Lines {
+		if l.Ope != Insert {
+			assert.Equal(t, i-countOpe(got.Lines[:i], Insert)+1, l.OldLineNumber)
+		}
+	}
+}
+
+func countOpe(lines []Line, ope Ope) int {
+	count := 0
+	for _, l := range lines {
+		if l.Ope == ope {
+			count++
+		}
+	}
+	return count
+}
+
+func TestDiffReaderReconstructTexts(t *testing.T) {
+	words := []string{"{", "}", "", "foo()", "bar()", "return"}
+	random := rand.New(rand.NewSource(1))
+	text := func() string {
+		var lines []string
+		for i := random.Intn(40); i > 0; i-- {
+			lines = append(lines, words[random.Intn(len(words))]+"\n")
+		}
+		return strings.Join(lines, "")
+	}
+	for i := 0; i < 200; i++ {
+		oldText, newText := text(), text()
+		for _, algorithm := range []DiffType{Myers, Patience, Histogram} {
+			result := collectLines(t, oldText, newText, Options{Type: LineByLine | algorithm}, 4)
+			assert.Equal(t, oldText, result.Old())
+			assert.Equal(t, newText, result.New())
+		}
+	}
+}
+
+func TestDiffReaderCallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	count := 0
+	err := DiffReader(strings.NewReader(src1), strings.NewReader(src2), Options{}, func(l Line) error {
+		count++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestUnifiedReader(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 50; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("line %d\n", i))
+		if i == 10 || i == 17 || i == 40 {
+			newLines = append(newLines, fmt.Sprintf("changed %d\n", i))
+		} else {
+			newLines = append(newLines, fmt.Sprintf("line %d\n", i))
+		}
+	}
+	oldText := strings.Join(oldLines, "")
+	newText := strings.Join(newLines, "")
+	for _, l := range []int{0, 3, 10} {
+		var builder strings.Builder
+		header := FileHeader{OldTitle: "old.txt", NewTitle: "new.txt"}
+		err := renderReader(&builder, strings.NewReader(oldText), strings.NewReader(newText), header, l, Options{Type: WordByWord}, 8, NewTagRenderer(PlainTag))
+		assert.Nil(t, err)
+		assert.Equal(t, Diff(oldText, newText, WordByWord).UnifiedWithTag("old.txt", "new.txt", l, PlainTag), builder.String())
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	// terminators are split by reads of one byte
+	r := newLineReader(iotest.OneByteReader(strings.NewReader("a\r\nb\rc\nd")), Options{})
+	assert.Nil(t, r.fill(10))
+	var lines []string
+	for _, l := range r.lines {
+		lines = append(lines, l.text)
+	}
+	assert.Equal(t, []string{"a\r\n", "b\r", "c\n", "d"}, lines)
+
+	// CR only text is read line by line, not at once
+	r = newLineReader(strings.NewReader(strings.Repeat("x\r", 100000)), Options{})
+	assert.Nil(t, r.fill(3))
+	assert.Equal(t, 3, len(r.lines))
+}
@@ -0,0 +1,95 @@
+package cdiff
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Tokenizer splits a line into tokens. Joined tokens should be the same as the line.
+type Tokenizer func(line string) []string
+
+// WordTokenizer splits a line on Unicode word boundaries (UAX #29)
+func WordTokenizer(line string) []string {
+	var result []string
+	state := -1
+	for line != "" {
+		var word string
+		word, line, state = uniseg.FirstWordInString(line, state)
+		result = append(result, word)
+	}
+	return result
+}
+
+// CharTokenizer splits a line into user-perceived characters (grapheme clusters of UAX #29)
+func CharTokenizer(line string) []string {
+	result := make([]string, 0, len(line))
+	state := -1
+	for line != "" {
+		var cluster string
+		cluster, line, _, state = uniseg.FirstGraphemeClusterInString(line, state)
+		result = append(result, cluster)
+	}
+	return result
+}
+
+func (o Options) tokenizer() Tokenizer {
+	if o.Tokenizer != nil {
+		return o.Tokenizer
+	}
+	switch o.Type & granularityMask {
+	case TokenByToken:
+		return WordTokenizer
+	case CharByChar:
+		return CharTokenizer
+	}
+	return nil
+}
+
+// fragmentDiffs returns diffs of changed lines. Changed fragments are aligned to tokens of the tokenizer
+// and grapheme clusters not to break characters like emoji sequences.
+func (o Options) fragmentDiffs(oldText, newText string) []diffmatchpatch.Diff {
+	dmp := diffmatchpatch.New()
+	tokenize := o.tokenizer()
+	if tokenize == nil {
+		return snapToGraphemes(dmp.DiffMain(oldText, newText, true), oldText, newText)
+	}
+	var tokens []string
+	ids := make(map[string]int)
+	toRunes := func(text string) []rune {
+		var result []rune
+		appendToken := func(token string) {
+			id, ok := ids[token]
+			if !ok {
+				id = len(tokens)
+				ids[token] = id
+				tokens = append(tokens, token)
+			}
+			result = append(result, idToRune(id))
+		}
+		for _, line := range splitLinesWithTerminator(text) {
+			line, terminator := splitTerminator(line)
+			for _, token := range tokenize(line) {
+				if token != "" {
+					appendToken(token)
+				}
+			}
+			if terminator != "" {
+				appendToken(terminator)
+			}
+		}
+		return result
+	}
+	oldRunes := toRunes(oldText)
+	newRunes := toRunes(newText)
+	diffs := dmp.DiffMainRunes(oldRunes, newRunes, false)
+	for i, diff := range diffs {
+		var builder strings.Builder
+		for _, r := range diff.Text {
+			builder.WriteString(tokens[runeToID(r)])
+		}
+		diffs[i].Text = builder.String()
+	}
+	return snapToGraphemes(diffs, oldText, newText)
+}
@@ -0,0 +1,60 @@
+package cdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordTokenizer(t *testing.T) {
+	assert.Equal(t, []string{"fooBar", "(", "x", ",", " ", "y_2", ")", " ", "日", "本", "語", "で", "す"}, WordTokenizer("fooBar(x, y_2) 日本語です"))
+	assert.Equal(t, []string{"a", "é", "b"}, CharTokenizer("aéb"))
+	assert.Equal(t, []string{"a", "e\u0301", "👨\u200d👩\u200d👧", "b"}, CharTokenizer("ae\u0301👨\u200d👩\u200d👧b"))
+}
+
+func TestDiffGranularity(t *testing.T) {
+	oldText := "x := fooBar(a)\n"
+	newText := "x := fooBaz(a, b)\n"
+	tests := []struct {
+		name    string
+		options Options
+		want    string
+	}{
+		{
+			name:    "word by word splits identifiers",
+			options: Options{Type: WordByWord},
+			want:    "- x := fooBa[r](a)\n+ x := fooBa[z](a[, b])\n",
+		},
+		{
+			name:    "token by token",
+			options: Options{Type: TokenByToken},
+			want:    "- x := [fooBar](a)\n+ x := [fooBaz](a[, b])\n",
+		},
+		{
+			name:    "char by char",
+			options: Options{Type: CharByChar},
+			want:    "- x := fooBa[r](a)\n+ x := fooBa[z](a[, b])\n",
+		},
+		{
+			name: "custom tokenizer",
+			options: Options{Type: WordByWord, Tokenizer: func(line string) []string {
+				return strings.SplitAfter(line, " ")
+			}},
+			want: "- x := [fooBar(a)]\n+ x := [fooBaz(a, b)]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DiffWithOptions(oldText, newText, tt.options)
+			assert.Equal(t, tt.want, dumpForTest(result))
+			assert.Equal(t, oldText, result.Old())
+			assert.Equal(t, newText, result.New())
+		})
+	}
+}
+
+func TestDiffGranularityMultiLine(t *testing.T) {
+	result := Diff("abc\ndef foo\nghi\n", "abc\ndeg foo\nghj\n", TokenByToken|Patience)
+	assert.Equal(t, "  abc\n- [def] foo\n- [ghi]\n+ [deg] foo\n+ [ghj]\n", dumpForTest(result))
+}